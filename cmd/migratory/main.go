@@ -3,7 +3,9 @@ package main
 import (
 	_ "github.com/ClickHouse/clickhouse-go"
 	"github.com/evgodev/migratory/internal/cli"
+	_ "github.com/go-sql-driver/mysql"
 	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
 )
 
 func main() {
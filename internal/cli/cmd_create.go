@@ -21,7 +21,7 @@ migratory create my_migration sql -d ./example/migrations`,
 	Args: cobra.ExactArgs(2),
 	Run: func(_ *cobra.Command, args []string) {
 		if err := create(config.Dir, args[0], args[1]); err != nil {
-			fmt.Printf("unable to create template: %s\n", err)
+			printFailure("unable to create template: %s", err)
 			os.Exit(1)
 		}
 	},
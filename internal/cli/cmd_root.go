@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 
 	cfg "github.com/evgodev/migratory/internal/config"
@@ -11,6 +12,10 @@ import (
 var (
 	configPath string
 	config     cfg.Config
+
+	verbose bool
+	quiet   bool
+	logger  *slog.Logger
 )
 
 // rootCmd represents the base command when called without any subcommands.
@@ -18,8 +23,8 @@ var rootCmd = &cobra.Command{
 	Use:   "migratory",
 	Short: "Migratory is a migration tool and go package",
 	Long: `Migratory is a migration tool and go package designed to simplify the 
-process of migrating data and schema between databases. Now it supports PostgreSQL 
-database dialect and allows you to choose between SQL or Go migration formats. 
+process of migrating data and schema between databases. Now it supports PostgreSQL, MySQL, 
+SQLite and ClickHouse database dialects and allows you to choose between SQL or Go migration formats. 
 With Migratory, you can easily create, apply, and roll back migrations using simple CLI commands. 
 To get started, simply refer to the README.md file or write command "help" for more information 
 on how to use this tool.`,
@@ -41,22 +46,71 @@ func init() {
 		"directory with .sql migration files")
 	rootCmd.PersistentFlags().StringVarP(&config.DSN, "db", "d", "",
 		"database connection string")
+	rootCmd.PersistentFlags().StringVar(&config.Dialect, "dialect", "",
+		"database dialect: postgres, mysql, sqlite or clickhouse (detected by DSN scheme if empty)")
 	rootCmd.PersistentFlags().StringVarP(&config.Table, "table", "t", "migrations",
 		"name of migrations table")
+	rootCmd.PersistentFlags().StringVarP(&config.Schema, "schema", "s", "",
+		`schema of migrations table, created if not exists (postgres only, default "public")`)
+	rootCmd.PersistentFlags().BoolVar(&config.Lock, "lock", false,
+		"hold a lock while migrating, so that concurrent runs wait for each other")
+	rootCmd.PersistentFlags().DurationVar(&config.LockTimeout, "lock-timeout", 0,
+		"maximum time to wait for the migrations lock (default 5m)")
+	rootCmd.PersistentFlags().BoolVar(&config.ValidateChecksums, "validate-checksums", false,
+		"refuse to apply migrations if applied ones were modified since they were applied")
+	rootCmd.PersistentFlags().BoolVar(&config.SkipPreflight, "skip-preflight", false,
+		"don't parse all pending migrations before applying the first one")
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false,
+		"log executed statements")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false,
+		"log errors only")
+	rootCmd.PersistentFlags().StringVar(&config.LogFormat, "log-format", "text",
+		"format of logs written to stderr: text or json")
+	rootCmd.MarkFlagsMutuallyExclusive("verbose", "quiet")
 
 	cobra.OnInitialize(initConfig)
 }
 
 func initConfig() {
 	if configPath == "" {
-		return
+		if config.Dialect == "" {
+			config.Dialect = cfg.DialectFromDSN(config.DSN)
+		}
+	} else {
+		newConfig, err := cfg.ReadConfig(configPath)
+		if err != nil {
+			printFailure("failed to read config: %s", err)
+			os.Exit(1)
+		}
+
+		config = *newConfig
 	}
 
-	newConfig, err := cfg.ReadConfig(configPath)
-	if err != nil {
-		fmt.Printf("failed to read config: %s\n", err)
+	var err error
+	if logger, err = newLogger(); err != nil {
+		printFailure("%s", err)
 		os.Exit(1)
 	}
+}
+
+// newLogger returns the logger of migrator runs writing to stderr in the format set by flags or config.
+// Migrations are logged by default, --verbose adds executed statements, --quiet leaves errors only.
+func newLogger() (*slog.Logger, error) {
+	level := slog.LevelInfo
+	switch {
+	case verbose:
+		level = slog.LevelDebug
+	case quiet:
+		level = slog.LevelError
+	}
+	opts := &slog.HandlerOptions{Level: level}
 
-	config = *newConfig
+	switch config.LogFormat {
+	case "", "text":
+		return slog.New(slog.NewTextHandler(os.Stderr, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(os.Stderr, opts)), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q, use text or json", config.LogFormat)
+	}
 }
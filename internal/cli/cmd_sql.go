@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/evgodev/migratory/internal/migrator"
+	"github.com/spf13/cobra"
+)
+
+var sqlCmd = &cobra.Command{
+	Use:   "sql [--from <version>] [--to <version>] [--down] [--dir <path>] [--dialect <dialect>] [-t <table>]",
+	Short: "Print migrations as a SQL script to be run by hand",
+	Long: `The "sql" command writes SQL migrations with ID greater than the "--from" version and less than or equal
+to the "--to" version into one script, which moves the database from the "--from" version to the "--to" one.
+With "--down", the script rolls back migrations from the "--from" version down to the "--to" one, starting from
+the last one. Transactional migrations are wrapped in BEGIN and COMMIT, migrations without transaction
+are not. Every migration is followed by the query recording it in the migrations table, or deleting it from
+the table, so the migrations table is consistent after the script is run. The script starts with creating
+the migrations table if not exists. By default, all migrations are written. The database is not connected,
+only the dialect is needed.`,
+	Example: `migratory sql --dialect postgres --dir example/migrations/ --from 20240101120000 > deploy.sql
+migratory sql -c /etc/config.yml --from 3 --to 5
+migratory sql -c /etc/config.yml --down --from 5 --to 3`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		down, err := cmd.Flags().GetBool("down")
+		if err != nil {
+			printFailure("failed to get bool --down flag")
+			os.Exit(1)
+		}
+
+		opts := migrator.ScriptOptions{From: 0, To: math.MaxInt64, Down: down}
+		if down {
+			opts.From, opts.To = math.MaxInt64, 0
+		}
+		if cmd.Flags().Changed("from") {
+			if opts.From, err = cmd.Flags().GetInt64("from"); err != nil {
+				printFailure("failed to get int --from flag")
+				os.Exit(1)
+			}
+		}
+		if cmd.Flags().Changed("to") {
+			if opts.To, err = cmd.Flags().GetInt64("to"); err != nil {
+				printFailure("failed to get int --to flag")
+				os.Exit(1)
+			}
+		}
+
+		if err = writeScript(config.Dir, config.Table, config.Dialect, opts); err != nil {
+			printError("unable to write SQL script", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(sqlCmd)
+
+	sqlCmd.Flags().Int64("from", 0, "version of the database before the script, 0 by default (max with --down)")
+	sqlCmd.Flags().Int64("to", 0, "version of the database after the script, max by default (0 with --down)")
+	sqlCmd.Flags().Bool("down", false, "write a script rolling back migrations")
+}
+
+func writeScript(dir, table, dialect string, opts migrator.ScriptOptions) error {
+	if dialect == "" {
+		return errors.New("unable to detect dialect by DSN, pass it with --dialect flag")
+	}
+
+	migrations, err := migrator.SeekMigrations(dir, config.Dialect)
+	if err != nil {
+		return fmt.Errorf("could not find migrations in directory %s: %w", dir, err)
+	}
+
+	var migratorOpts []migrator.Option
+	if config.Schema != "" {
+		migratorOpts = append(migratorOpts, migrator.WithSchema(config.Schema))
+	}
+
+	return migrator.WriteScript(os.Stdout, dialect, table, migrations, opts, migratorOpts...)
+}
@@ -3,31 +3,36 @@ package cli
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"os"
+	"strconv"
 
+	cfg "github.com/evgodev/migratory/internal/config"
 	"github.com/evgodev/migratory/internal/migrator"
 )
 
-func rollback(dir, table, dialect string, redo bool) error {
-	db, err := sql.Open("postgres", config.DSN)
+func rollback(dir, table, dialect string, redo bool, opts ...migrator.Option) error {
+	db, err := openDB(dialect)
 	if err != nil {
-		return fmt.Errorf("could not open database: %w", err)
+		return err
 	}
 
 	defer func() {
 		err = db.Close()
 		if err != nil {
-			fmt.Println("failed to close database connection")
+			printFailure("failed to close database connection")
 		}
 	}()
 
-	migrations, err := migrator.SeekMigrations(dir)
+	migrations, sourceOpts, err := seekMigrations(dir)
 	if err != nil {
-		return fmt.Errorf("could not find migrations in directory %s: %w", dir, err)
+		return err
 	}
 
 	ctx := context.Background()
-	m, err := migrator.New(ctx, db, dialect, table)
+	opts = append(opts, sourceOpts...)
+	m, err := migrator.New(ctx, db, dialect, table, append(migratorOptions(), opts...)...)
 	if err != nil {
 		return fmt.Errorf("failed to create migrator: %w", err)
 	}
@@ -38,3 +43,204 @@ func rollback(dir, table, dialect string, redo bool) error {
 
 	return nil
 }
+
+// runMigrator opens the database, finds migrations in the directory and calls fn with a new migrator.
+func runMigrator(
+	dir, table, dialect string,
+	fn func(ctx context.Context, m *migrator.Migrator, migrations migrator.Migrations, db *sql.DB) error,
+) error {
+	db, err := openDB(dialect)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = db.Close()
+		if err != nil {
+			printFailure("failed to close database connection")
+		}
+	}()
+
+	migrations, sourceOpts, err := seekMigrations(dir)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	m, err := migrator.New(ctx, db, dialect, table, append(migratorOptions(), sourceOpts...)...)
+	if err != nil {
+		return fmt.Errorf("failed to create migrator: %w", err)
+	}
+
+	return fn(ctx, m, migrations, db)
+}
+
+// seekMigrations finds migrations in the directory. SQL callback files and repeatable migrations
+// found there are returned as migrator options.
+func seekMigrations(dir string) (migrator.Migrations, []migrator.Option, error) {
+	migrations, err := migrator.SeekMigrations(dir, config.Dialect)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not find migrations in directory %s: %w", dir, err)
+	}
+
+	callbacks, err := seekCallbacks(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	repeatables, err := seekRepeatables(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return migrations, []migrator.Option{migrator.WithCallbacks(callbacks), migrator.WithRepeatables(repeatables)}, nil
+}
+
+func seekCallbacks(dir string) (*migrator.Callbacks, error) {
+	callbacks, err := migrator.SeekCallbacks(dir, config.Dialect)
+	if err != nil {
+		return nil, fmt.Errorf("could not read callbacks in directory %s: %w", dir, err)
+	}
+	return callbacks, nil
+}
+
+func seekRepeatables(dir string) (migrator.Migrations, error) {
+	repeatables, err := migrator.SeekRepeatables(dir, config.Dialect)
+	if err != nil {
+		return nil, fmt.Errorf("could not find repeatable migrations in directory %s: %w", dir, err)
+	}
+	return repeatables, nil
+}
+
+// parseVersion parses a migration ID passed as a command argument.
+func parseVersion(arg string) (int64, error) {
+	version, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("version must be an integer migration ID, got %q", arg)
+	}
+	return version, nil
+}
+
+// openDB opens the database with the driver of the dialect.
+func openDB(dialect string) (*sql.DB, error) {
+	if dialect == "" {
+		return nil, errors.New("unable to detect dialect by DSN, pass it with --dialect flag")
+	}
+
+	driver, err := cfg.LookupDriver(dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open(driver.Name, driver.DSN(config.DSN))
+	if err != nil {
+		return nil, fmt.Errorf("could not open database: %w", err)
+	}
+
+	return db, nil
+}
+
+// migratorOptions returns migrator options set by flags or config.
+func migratorOptions() []migrator.Option {
+	opts := []migrator.Option{migrator.WithLogger(logger)}
+	if config.Schema != "" {
+		opts = append(opts, migrator.WithSchema(config.Schema))
+	}
+	if config.ValidateChecksums {
+		opts = append(opts, migrator.WithChecksumValidation())
+	}
+	if !config.SkipPreflight {
+		opts = append(opts, migrator.WithPreflight())
+	}
+	if config.Lock || config.LockTimeout > 0 {
+		opts = append(opts, migrator.WithLock(migrator.LockOptions{Timeout: config.LockTimeout}))
+	}
+	return opts
+}
+
+// printError prints the error after the message. Parse errors of migration files are printed compiler-style
+// each on its own line, e.g. `migrations/42_users.sql:3:1: unknown migration command "statment_end"`.
+// With --log-format json they are logged instead, see printFailure.
+func printError(msg string, err error) {
+	parseErrors := collectParseErrors(err)
+	if len(parseErrors) > 0 {
+		for _, parseErr := range parseErrors {
+			printFailure("%s", parseErr)
+		}
+		printFailure("%s", msg)
+		return
+	}
+
+	printFailure("%s: %s", msg, err)
+}
+
+// printMessage prints the outcome of a command. With --log-format json it's logged at info level instead,
+// so that stderr has JSON only, and stdout has the output of the command only (e.g. a plan or a SQL script).
+func printMessage(format string, args ...any) {
+	if config.LogFormat == "json" && logger != nil {
+		logger.Info(fmt.Sprintf(format, args...))
+		return
+	}
+	fmt.Printf(format+"\n", args...)
+}
+
+// printFailure prints why a command failed, or logs it at error level with --log-format json, see printMessage.
+func printFailure(format string, args ...any) {
+	if config.LogFormat == "json" && logger != nil {
+		logger.Error(fmt.Sprintf(format, args...))
+		return
+	}
+	fmt.Printf(format+"\n", args...)
+}
+
+// collectParseErrors returns all parse errors in the tree of err, including joined errors.
+func collectParseErrors(err error) []*migrator.ParseError {
+	switch e := err.(type) { //nolint:errorlint // The tree is walked by hand to find every parse error.
+	case nil:
+		return nil
+	case *migrator.ParseError:
+		return []*migrator.ParseError{e}
+	case interface{ Unwrap() []error }:
+		var collected []*migrator.ParseError
+		for _, wrapped := range e.Unwrap() {
+			collected = append(collected, collectParseErrors(wrapped)...)
+		}
+		return collected
+	case interface{ Unwrap() error }:
+		return collectParseErrors(e.Unwrap())
+	default:
+		return nil
+	}
+}
+
+// planRollback prints migrations the down (or redo) command would run without running them.
+func planRollback(redo bool) {
+	plan := &migrator.Plan{}
+	if err := rollback(config.Dir, config.Table, config.Dialect, redo, migrator.WithDryRun(plan)); err != nil {
+		printError("unable to plan rollback", err)
+		os.Exit(1)
+	}
+
+	printPlan(plan)
+	printMessage("dry run: nothing was executed")
+}
+
+// printPlan prints migrations of a dry run with their statements as a SQL script.
+func printPlan(plan *migrator.Plan) {
+	for _, planned := range plan.Migrations {
+		txMode := "transaction"
+		if planned.NoTx {
+			txMode = "no transaction"
+		}
+		id := strconv.FormatInt(planned.ID, 10)
+		if planned.Repeatable {
+			id = "R"
+		}
+		fmt.Printf("-- %s %s: %s, %s\n", id, planned.Name, planned.Direction, txMode)
+
+		for _, statement := range planned.Statements {
+			fmt.Print(statement)
+		}
+		fmt.Println()
+	}
+}
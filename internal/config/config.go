@@ -5,17 +5,32 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/evgodev/migratory/internal/migrator"
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	Dir   string `yaml:"directory"`
-	DSN   string `yaml:"dsn"`
-	Table string `yaml:"table"`
+	Dir    string `yaml:"directory"`
+	DSN    string `yaml:"dsn"`
+	Table  string `yaml:"table"`
+	Schema string `yaml:"schema"`
 
-	Dialect string
+	Lock        bool          `yaml:"lock"`
+	LockTimeout time.Duration `yaml:"lock_timeout"`
+
+	// ValidateChecksums makes up commands refuse to run if applied migrations were modified.
+	ValidateChecksums bool `yaml:"validate_checksums"`
+	// SkipPreflight makes up commands parse migrations one by one while applying them,
+	// instead of parsing all pending ones first.
+	SkipPreflight bool `yaml:"skip_preflight"`
+
+	// LogFormat is the format of logs written to stderr: "text" (default) or "json".
+	LogFormat string `yaml:"log_format"`
+
+	// Dialect is detected by the DSN scheme if not set explicitly.
+	Dialect string `yaml:"dialect"`
 }
 
 var defaultConfig = Config{
@@ -41,24 +56,32 @@ func ReadConfig(path string) (*Config, error) {
 		return nil, fmt.Errorf("%w: %w", ErrUnmarshalFailure, err)
 	}
 
-	cfg.Dialect = dialectFromDSN(cfg.DSN)
+	if cfg.Dialect == "" {
+		cfg.Dialect = DialectFromDSN(cfg.DSN)
+	}
 
 	setDefaultValues(cfg)
 
 	return cfg, nil
 }
 
-func dialectFromDSN(dsn string) string {
+// DialectFromDSN detects the dialect by the DSN scheme, e.g. "postgres://...".
+// It returns an empty string if the scheme is unknown.
+func DialectFromDSN(dsn string) string {
 	index := strings.Index(dsn, ":")
 	if index == -1 || len(dsn) == 0 {
 		return ""
 	}
 	firstWord := dsn[:index]
 	switch firstWord {
-	case "postgres":
+	case "postgres", "postgresql":
 		return migrator.Postgres
 	case "clickhouse":
 		return migrator.ClickHouse
+	case "mysql":
+		return migrator.MySQL
+	case "sqlite", "file":
+		return migrator.SQLite
 	default:
 		return ""
 	}
@@ -0,0 +1,193 @@
+package migrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/evgodev/migratory/internal/migrator/executor"
+	"github.com/evgodev/migratory/internal/migrator/parser"
+	"github.com/evgodev/migratory/internal/migrator/store"
+)
+
+// Reserved names of SQL callback files in the migrations directory.
+const (
+	CallbackBeforeAll  = "beforeMigrate.sql"
+	CallbackAfterAll   = "afterMigrate.sql"
+	CallbackBeforeEach = "beforeEachMigrate.sql"
+	CallbackAfterEach  = "afterEachMigrate.sql"
+)
+
+// Callbacks are SQL files in the migrations directory run at the matching points of migration runs,
+// like Hooks: beforeMigrate.sql and afterMigrate.sql around the whole run, beforeEachMigrate.sql
+// and afterEachMigrate.sql around every migration, in its transaction if it has one.
+// Callback files have the format of migrations, the up section is run by up runs and the down section
+// by rollbacks. Their transaction mode follows the migration, no_transaction is ignored.
+// Callback files are parsed lazily, when a run has migrations to apply or roll back, or by Parse.
+type Callbacks struct {
+	beforeAll, afterAll, beforeEach, afterEach *callback
+}
+
+type callback struct {
+	preparer *sqlPreparer
+	parsed   *parser.ParsedMigration
+}
+
+// WithCallbacks makes the Migrator run the SQL callback files found by SeekCallbacks.
+// They are run before the hooks set by WithHooks at the same points.
+func WithCallbacks(callbacks *Callbacks) Option {
+	return func(m *Migrator) {
+		m.callbacks = callbacks
+	}
+}
+
+// IsCallbackFile reports whether the file has a reserved name of a SQL callback file.
+func IsCallbackFile(filePath string) bool {
+	switch filepath.Base(filePath) {
+	case CallbackBeforeAll, CallbackAfterAll, CallbackBeforeEach, CallbackAfterEach:
+		return true
+	default:
+		return false
+	}
+}
+
+// SeekCallbacks finds SQL callback files in the given directory of the OS file system.
+// Callbacks not found are skipped, so the result is never nil.
+func SeekCallbacks(dir, dialect string) (*Callbacks, error) {
+	return SeekCallbacksFS(osFS{}, dir, dialect)
+}
+
+// SeekCallbacksFS finds SQL callback files in the given directory of fsys, e.g. embed.FS.
+// They are parsed later in the syntax of the dialect, like migrations.
+func SeekCallbacksFS(fsys fs.FS, dir, dialect string) (*Callbacks, error) {
+	callbacks := &Callbacks{}
+	for _, file := range []struct {
+		name     string
+		callback **callback
+	}{
+		{name: CallbackBeforeAll, callback: &callbacks.beforeAll},
+		{name: CallbackAfterAll, callback: &callbacks.afterAll},
+		{name: CallbackBeforeEach, callback: &callbacks.beforeEach},
+		{name: CallbackAfterEach, callback: &callbacks.afterEach},
+	} {
+		filePath := joinPath(fsys, dir, file.name)
+		if _, err := fs.Stat(fsys, filePath); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to check callback file %s: %w", filePath, err)
+		}
+
+		*file.callback = &callback{preparer: newSQLPreparer(fsys, filePath, parserOptions(dialect))}
+	}
+
+	return callbacks, nil
+}
+
+// Parse parses the callback files that are not parsed yet. It returns ErrInvalidMigrations
+// with the parse errors of all invalid ones (see ParseError).
+func (c *Callbacks) Parse() error {
+	if c == nil {
+		return nil
+	}
+
+	var errs []error
+	for _, cb := range []*callback{c.beforeAll, c.afterAll, c.beforeEach, c.afterEach} {
+		if err := cb.parse(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%w:\n%w", ErrInvalidMigrations, errors.Join(errs...))
+	}
+
+	return nil
+}
+
+func (c *callback) parse() error {
+	if c == nil || c.parsed != nil {
+		return nil
+	}
+
+	parsed, err := c.preparer.Parse()
+	if err != nil {
+		return err
+	}
+	c.parsed = parsed
+	return nil
+}
+
+// hooks returns the callbacks as hooks, a missing callback is a nil hook.
+func (c *Callbacks) hooks() Hooks {
+	if c == nil {
+		return Hooks{}
+	}
+
+	return Hooks{
+		BeforeAll:  c.beforeAll.hook(),
+		AfterAll:   c.afterAll.hook(),
+		BeforeEach: c.beforeEach.hook(),
+		AfterEach:  c.afterEach.hook(),
+	}
+}
+
+// hook returns a hook executing the statements of the callback in the direction of the run,
+// in the transaction of the migration if it has one.
+func (c *callback) hook() HookFn {
+	if c == nil {
+		return nil
+	}
+
+	return func(ctx context.Context, info HookInfo) error {
+		if err := c.parse(); err != nil {
+			return err
+		}
+		up, down := c.parsed.UpStatements, c.parsed.DownStatements
+
+		var err error
+		switch {
+		case info.Tx != nil && info.Direction == store.DirectionDown:
+			err = executor.NewSQLExecutor(up, down).DownTx(ctx, info.Tx)
+		case info.Tx != nil:
+			err = executor.NewSQLExecutor(up, down).UpTx(ctx, info.Tx)
+		case info.Direction == store.DirectionDown:
+			err = executor.NewSQLExecutorNoTx(up, down).Down(ctx, info.DB)
+		default:
+			err = executor.NewSQLExecutorNoTx(up, down).Up(ctx, info.DB)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to run callback %s: %w", filepath.Base(c.preparer.filePath), err)
+		}
+		return nil
+	}
+}
+
+// joinHooks returns hooks calling the first hooks and then the second ones at every point.
+func joinHooks(first, second Hooks) Hooks {
+	return Hooks{
+		BeforeAll:  joinHookFns(first.BeforeAll, second.BeforeAll),
+		AfterAll:   joinHookFns(first.AfterAll, second.AfterAll),
+		BeforeEach: joinHookFns(first.BeforeEach, second.BeforeEach),
+		AfterEach:  joinHookFns(first.AfterEach, second.AfterEach),
+		OnError:    second.OnError,
+	}
+}
+
+func joinHookFns(first, second HookFn) HookFn {
+	if first == nil {
+		return second
+	}
+	if second == nil {
+		return first
+	}
+
+	return func(ctx context.Context, info HookInfo) error {
+		if err := first(ctx, info); err != nil {
+			return err
+		}
+		return second(ctx, info)
+	}
+}
@@ -0,0 +1,57 @@
+package migrator
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/evgodev/migratory/internal/require"
+)
+
+func TestSeekCallbacksFS(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		fsys      fstest.MapFS
+		wantErr   error
+		wantFiles []string
+	}{
+		"valid": {
+			fsys: fstest.MapFS{
+				"migrations/" + CallbackBeforeAll: {Data: []byte("-- +migrate up\nSELECT 1;\n")},
+				"migrations/" + CallbackAfterEach: {Data: []byte("-- +migrate up\nSELECT 2;\n")},
+			},
+		},
+		"no callbacks": {
+			fsys: fstest.MapFS{"migrations/1_first.sql": {Data: []byte("-- +migrate up\nSELECT 1;\n")}},
+		},
+		"invalid in order of run": {
+			fsys: fstest.MapFS{
+				"migrations/" + CallbackAfterEach:  {Data: []byte("-- +migrate up\nSELECT 1\n")},
+				"migrations/" + CallbackBeforeEach: {Data: []byte("-- +migrate up\nSELECT 2\n")},
+				"migrations/" + CallbackAfterAll:   {Data: []byte("-- +migrate up\nSELECT 3\n")},
+				"migrations/" + CallbackBeforeAll:  {Data: []byte("-- +migrate up\nSELECT 4\n")},
+			},
+			wantErr:   ErrInvalidMigrations,
+			wantFiles: []string{CallbackBeforeAll, CallbackAfterAll, CallbackBeforeEach, CallbackAfterEach},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			// Callback files are not parsed when they are found.
+			callbacks, err := SeekCallbacksFS(test.fsys, "migrations", Postgres)
+			require.NoError(t, err, "SeekCallbacksFS(...) error")
+
+			err = callbacks.Parse()
+			require.ErrorIs(t, err, test.wantErr, "callbacks.Parse() error")
+			last := -1
+			for _, file := range test.wantFiles {
+				i := strings.Index(err.Error(), file)
+				require.Bool(t, i > last, true, "callbacks.Parse() must report "+file+" in order")
+				last = i
+			}
+		})
+	}
+}
@@ -3,14 +3,33 @@ package executor
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
 )
 
+var ErrStatementsChanged = errors.New("statements executed before the migration was interrupted have changed")
+
+type loggerKey struct{}
+
+// WithLogger returns a context making SQL executors log every statement at debug level before executing it.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+func logStatement(ctx context.Context, query string) {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		logger.DebugContext(ctx, "executing statement", "statement", query)
+	}
+}
+
 type QueryExecutor interface {
 	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
 }
 
 func execute(ctx context.Context, executor QueryExecutor, statements []string) error {
 	for _, query := range statements {
+		logStatement(ctx, query)
 		_, err := executor.ExecContext(ctx, query)
 		if err != nil {
 			return err
@@ -18,3 +37,23 @@ func execute(ctx context.Context, executor QueryExecutor, statements []string) e
 	}
 	return nil
 }
+
+func executeFrom(
+	ctx context.Context, executor QueryExecutor, statements []string, from int, progress ProgressFn,
+) error {
+	if from > len(statements) {
+		return fmt.Errorf("%w: %d statements, %d executed", ErrStatementsChanged, len(statements), from)
+	}
+
+	for i := from; i < len(statements); i++ {
+		logStatement(ctx, statements[i])
+		if _, err := executor.ExecContext(ctx, statements[i]); err != nil {
+			return fmt.Errorf("failed to execute statement %d: %w", i+1, err)
+		}
+		if err := progress(ctx, i+1); err != nil {
+			return fmt.Errorf("failed to record progress of statement %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
@@ -5,6 +5,10 @@ import (
 	"database/sql"
 )
 
+// ProgressFn is called after each statement executed without transaction
+// with the number of statements executed so far, including the skipped ones.
+type ProgressFn func(ctx context.Context, executed int) error
+
 type SQLExecutorNoTx struct {
 	statements statements
 }
@@ -25,3 +29,13 @@ func (s SQLExecutorNoTx) Up(ctx context.Context, db *sql.DB) error {
 func (s SQLExecutorNoTx) Down(ctx context.Context, db *sql.DB) error {
 	return execute(ctx, db, s.statements.down)
 }
+
+// UpFrom executes up statements skipping the first `from` ones, which were executed by an interrupted run.
+func (s SQLExecutorNoTx) UpFrom(ctx context.Context, db *sql.DB, from int, progress ProgressFn) error {
+	return executeFrom(ctx, db, s.statements.up, from, progress)
+}
+
+// DownFrom executes down statements skipping the first `from` ones, which were executed by an interrupted run.
+func (s SQLExecutorNoTx) DownFrom(ctx context.Context, db *sql.DB, from int, progress ProgressFn) error {
+	return executeFrom(ctx, db, s.statements.down, from, progress)
+}
@@ -3,6 +3,8 @@ package migrator
 import (
 	"context"
 	"database/sql"
+
+	"github.com/evgodev/migratory/internal/migrator/executor"
 )
 
 type ExecutorTx interface {
@@ -15,6 +17,14 @@ type ExecutorDB interface {
 	Down(ctx context.Context, db *sql.DB) error
 }
 
+// ResumableExecutorDB is implemented by executors of SQL migrations without transaction,
+// which can skip statements executed by an interrupted run and report progress after each statement.
+type ResumableExecutorDB interface {
+	ExecutorDB
+	UpFrom(ctx context.Context, db *sql.DB, from int, progress executor.ProgressFn) error
+	DownFrom(ctx context.Context, db *sql.DB, from int, progress executor.ProgressFn) error
+}
+
 // executors encapsulates execution logic for database migrations,
 // supporting both transactional and non-transactional modes.
 // It holds either an ExecutorTx or an ExecutorDB to execute migrations based on the execution context.
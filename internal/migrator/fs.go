@@ -0,0 +1,33 @@
+package migrator
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// osFS implements fs.FS on top of the OS file system. Unlike os.DirFS it accepts any OS path,
+// including relative and absolute ones, so paths in errors stay exactly as the user passed them.
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error) {
+	return os.Open(name)
+}
+
+func (osFS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (osFS) Glob(pattern string) ([]string, error) {
+	return filepath.Glob(pattern)
+}
+
+// joinPath joins path elements using OS separators for the OS file system
+// and forward slashes for any other fs.FS, as required by the io/fs package.
+func joinPath(fsys fs.FS, elem ...string) string {
+	if _, ok := fsys.(osFS); ok {
+		return filepath.Join(elem...)
+	}
+	return path.Join(elem...)
+}
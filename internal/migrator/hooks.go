@@ -0,0 +1,130 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Hooks are functions called around migration runs, e.g. to refresh materialized views after migrations
+// or to reset search_path before each one. Any of them may be nil. Hooks are not called by dry runs.
+type Hooks struct {
+	// BeforeAll is called before the first migration of a run, if there is any to apply or roll back.
+	// An error aborts the run.
+	BeforeAll HookFn
+	// AfterAll is called after the last migration of a run succeeded.
+	AfterAll HookFn
+	// BeforeEach is called before every migration, in its transaction if it has one. An error aborts the run.
+	BeforeEach HookFn
+	// AfterEach is called after every migration is run and recorded, in its transaction if it has one,
+	// so an error rolls the migration back. A migration without transaction stays recorded,
+	// the error stops the run, and the migration is counted as run.
+	AfterEach HookFn
+	// OnError is called when a migration fails, including failures of BeforeEach and AfterEach,
+	// after its transaction is rolled back.
+	OnError func(ctx context.Context, info HookInfo, err error)
+}
+
+// HookFn is a hook called around migrations.
+type HookFn func(ctx context.Context, info HookInfo) error
+
+// HookInfo describes the migration a hook is called for.
+type HookInfo struct {
+	// ID and Name of the migration, empty for BeforeAll and AfterAll. ID is 0 for repeatable migrations.
+	ID   int64
+	Name string
+	// Direction of the run, "up" or "down". Redo is a single "down" run for BeforeAll and AfterAll.
+	Direction string
+	// Tx is the transaction of the migration, nil if the migration is run without one,
+	// and for BeforeAll, AfterAll and OnError.
+	Tx *sql.Tx
+	// DB is the database the migrations are run on.
+	DB *sql.DB
+}
+
+// WithHooks sets the hooks called around migration runs.
+func WithHooks(hooks Hooks) Option {
+	return func(m *Migrator) {
+		m.hooks = hooks
+	}
+}
+
+func callHook(ctx context.Context, hook HookFn, name string, info HookInfo) error {
+	if hook == nil {
+		return nil
+	}
+	if err := hook(ctx, info); err != nil {
+		return fmt.Errorf("%s hook failed: %w", name, err)
+	}
+	return nil
+}
+
+// recordedError is an error of the AfterEach hook of a migration run without transaction.
+// The migration is recorded as applied (rolled back) before the hook is called, so it isn't a failed one.
+type recordedError struct {
+	err error
+}
+
+func (e *recordedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *recordedError) Unwrap() error {
+	return e.err
+}
+
+// callAfterRecorded calls the AfterEach hook of a migration run without transaction, see recordedError.
+func callAfterRecorded(ctx context.Context, hook HookFn, info HookInfo) error {
+	if err := callHook(ctx, hook, "after each", info); err != nil {
+		return &recordedError{err: err}
+	}
+	return nil
+}
+
+// isRecorded reports whether the migration run that returned err is recorded nonetheless.
+func isRecorded(err error) bool {
+	var recorded *recordedError
+	return errors.As(err, &recorded)
+}
+
+func newHookInfo(migration *Migration, direction string, db *sql.DB, tx *sql.Tx) HookInfo {
+	return HookInfo{
+		ID:        migration.ID(),
+		Name:      migration.Name(),
+		Direction: direction,
+		Tx:        tx,
+		DB:        db,
+	}
+}
+
+// runAll calls fn, which runs migrations in the direction, between the BeforeAll and AfterAll hooks.
+func (m Migrator) runAll(ctx context.Context, db *sql.DB, direction string, fn func() error) error {
+	if m.plan != nil {
+		return fn()
+	}
+
+	// Callback files are parsed before the run, so an invalid one doesn't stop it halfway.
+	if err := m.callbacks.Parse(); err != nil {
+		return err
+	}
+
+	info := HookInfo{Direction: direction, DB: db}
+	if err := callHook(ctx, m.hooks.BeforeAll, "before all", info); err != nil {
+		return err
+	}
+
+	if err := fn(); err != nil {
+		return err
+	}
+
+	return callHook(ctx, m.hooks.AfterAll, "after all", info)
+}
+
+// rollbackTx rolls back the transaction after err, the rollback error is added to it.
+func rollbackTx(tx *sql.Tx, err error) error {
+	if txErr := tx.Rollback(); txErr != nil {
+		return fmt.Errorf("%w; failed to rollback transaction: %w", err, txErr)
+	}
+	return err
+}
@@ -0,0 +1,134 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/evgodev/migratory/internal/migrator/parser"
+	"github.com/evgodev/migratory/internal/require"
+	_ "modernc.org/sqlite"
+)
+
+func TestRedoHooks(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"1_create_a.sql": {Data: []byte("-- +migrate up\nCREATE TABLE a (id int);\n-- +migrate down\nDROP TABLE a;\n")},
+	}
+	migrations := Migrations{newSQLMigration(1, "create_a", fsys, "1_create_a.sql", parser.Options{})}
+
+	var called []string
+	record := func(hook string) HookFn {
+		return func(_ context.Context, info HookInfo) error {
+			called = append(called, fmt.Sprintf("%s %s %d", hook, info.Direction, info.ID))
+			return nil
+		}
+	}
+	db := openSQLite(t)
+	m, err := New(ctx, db, SQLite, "migrations", WithHooks(Hooks{
+		BeforeAll:  record("before all"),
+		AfterAll:   record("after all"),
+		BeforeEach: record("before each"),
+		AfterEach:  record("after each"),
+	}))
+	require.NoError(t, err, "New(...) error")
+
+	_, err = m.Up(ctx, migrations, db, false)
+	require.NoError(t, err, "m.Up(...) error")
+	called = nil
+
+	require.NoError(t, m.Down(ctx, migrations, db, true), "m.Down(...) redo error")
+	require.Equal(t, called, []string{
+		"before all down 0",
+		"before each down 1",
+		"after each down 1",
+		"before each up 1",
+		"after each up 1",
+		"after all down 0",
+	}, "called hooks")
+}
+
+func TestAfterEachError(t *testing.T) {
+	errHook := errors.New("hook error")
+	tests := map[string]struct {
+		upOption    string
+		wantApplied int
+		wantIDs     []int64
+	}{
+		"up": {
+			upOption:    "",
+			wantApplied: 1,
+			wantIDs:     []int64{1},
+		},
+		"up no transaction": {
+			upOption:    " no_transaction",
+			wantApplied: 2,
+			wantIDs:     []int64{1, 2},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			fsys := fstest.MapFS{
+				"1_create_a.sql": {Data: []byte("-- +migrate up\nCREATE TABLE a (id int);\n-- +migrate down\nDROP TABLE a;\n")},
+				"2_create_b.sql": {
+					Data: []byte("-- +migrate up" + test.upOption + "\nCREATE TABLE b (id int);\n" +
+						"-- +migrate down\nDROP TABLE b;\n"),
+				},
+				"3_create_c.sql": {Data: []byte("-- +migrate up\nCREATE TABLE c (id int);\n-- +migrate down\nDROP TABLE c;\n")},
+			}
+			migrations := Migrations{
+				newSQLMigration(1, "create_a", fsys, "1_create_a.sql", parser.Options{}),
+				newSQLMigration(2, "create_b", fsys, "2_create_b.sql", parser.Options{}),
+				newSQLMigration(3, "create_c", fsys, "3_create_c.sql", parser.Options{}),
+			}
+
+			var failed []int64
+			db := openSQLite(t)
+			m, err := New(ctx, db, SQLite, "migrations", WithHooks(Hooks{
+				AfterEach: func(_ context.Context, info HookInfo) error {
+					if info.ID == 2 {
+						return errHook
+					}
+					return nil
+				},
+				OnError: func(_ context.Context, info HookInfo, _ error) {
+					failed = append(failed, info.ID)
+				},
+			}))
+			require.NoError(t, err, "New(...) error")
+
+			appliedCount, err := m.Up(ctx, migrations, db, false)
+			require.ErrorIs(t, err, errHook, "m.Up(...) error")
+			require.Int(t, appliedCount, test.wantApplied, "m.Up(...) applied count")
+			require.Equal(t, failed, []int64{2}, "OnError(...) migrations")
+
+			applied, err := m.getAppliedMigrations(ctx, db)
+			require.NoError(t, err, "m.getAppliedMigrations(...) error")
+			var ids []int64
+			for _, result := range applied {
+				ids = append(ids, result.ID)
+			}
+			require.Equal(t, ids, test.wantIDs, "recorded migrations")
+		})
+	}
+}
+
+func openSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err, "sql.Open(...) error")
+	t.Cleanup(func() {
+		require.NoError(t, db.Close(), "db.Close()")
+	})
+
+	return db
+}
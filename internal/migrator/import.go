@@ -0,0 +1,370 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/evgodev/migratory/internal/migrator/store"
+)
+
+// Migration tools whose history of applied migrations can be imported by Import.
+const (
+	ImportGoose         = "goose"
+	ImportGolangMigrate = "golang-migrate"
+	ImportSQLMigrate    = "sql-migrate"
+	ImportFlyway        = "flyway"
+)
+
+var (
+	ErrUnknownImportTool = errors.New("unknown migration tool to import from")
+	ErrFailedImport      = errors.New("history of the migration tool has a failed or dirty migration")
+)
+
+// ImportOptions selects the history imported by Import.
+type ImportOptions struct {
+	// From is the migration tool the history is imported from: ImportGoose, ImportGolangMigrate,
+	// ImportSQLMigrate or ImportFlyway.
+	From string
+	// Table is the version table of the tool, by default the one the tool creates: goose_db_version,
+	// schema_migrations, gorp_migrations or flyway_schema_history.
+	Table string
+	// DryRun makes Import return the migrations it would record without recording them.
+	DryRun bool
+	// Force makes Import record migrations not applied yet if the migrations table has rows.
+	Force bool
+}
+
+// importedMigration is a migration applied by another migration tool.
+type importedMigration struct {
+	id        int64
+	appliedAt time.Time
+}
+
+// historyTime is the time a migration was applied at, scanned from a driver time or from text,
+// e.g. Flyway stores installed_on as text on SQLite.
+type historyTime struct {
+	time.Time
+}
+
+// historyTimeLayouts are layouts of times stored as text, they are parsed in UTC.
+var historyTimeLayouts = []string{"2006-01-02 15:04:05.999999999", time.RFC3339Nano, "2006-01-02T15:04:05.999999999"}
+
+func (t *historyTime) Scan(src any) error {
+	var text string
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case time.Time:
+		t.Time = v
+		return nil
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	default:
+		return fmt.Errorf("unsupported type %T of applied time", src)
+	}
+
+	for _, layout := range historyTimeLayouts {
+		if parsed, err := time.Parse(layout, text); err == nil {
+			t.Time = parsed
+			return nil
+		}
+	}
+	return fmt.Errorf("failed to parse applied time %q", text)
+}
+
+// historyReader reads the migrations applied by another tool from its version table.
+type historyReader func(
+	ctx context.Context, db *sql.DB, table string, migrations Migrations,
+) ([]importedMigration, error)
+
+// Import records migrations applied by another migration tool as applied, with the time they were applied at,
+// so that a project can switch to migratory without running its migrations again. The version table of the tool
+// is only read. Migrations applied by the tool must be among migrations, otherwise ErrMigrationNotFound
+// is returned. golang-migrate stores the version only: migrations with ID less than or equal to it are imported,
+// recorded as applied now. Import returns ErrNotEmptyTable if the migrations table has rows, unless opts.Force
+// is set, then only migrations not applied yet are recorded. It returns the imported migrations ordered by ID.
+func (m Migrator) Import(
+	ctx context.Context, migrations Migrations, db *sql.DB, opts ImportOptions,
+) (imported []MigrationResult, err error) {
+	err = m.withLock(ctx, db, func() error {
+		imported, err = m.importHistory(ctx, migrations, db, opts)
+		return err
+	})
+	return imported, err
+}
+
+func (m Migrator) importHistory(
+	ctx context.Context, migrations Migrations, db *sql.DB, opts ImportOptions,
+) ([]MigrationResult, error) {
+	read, table, err := m.historyReader(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	appliedMigrations, err := m.getAppliedMigrations(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+	if len(appliedMigrations) > 0 && !opts.Force {
+		return nil, fmt.Errorf("%w: %d migration(s) recorded", ErrNotEmptyTable, len(appliedMigrations))
+	}
+	applied := make(map[int64]struct{}, len(appliedMigrations))
+	for _, r := range appliedMigrations {
+		applied[r.ID] = struct{}{}
+	}
+
+	history, err := read(ctx, db, table, migrations)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s history from table %s: %w", opts.From, table, err)
+	}
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].id < history[j].id
+	})
+
+	imported := make([]MigrationResult, 0, len(history))
+	for _, h := range history {
+		if _, ok := applied[h.id]; ok {
+			continue
+		}
+		migration, err := findMigration(migrations, h.id)
+		if err != nil {
+			return nil, fmt.Errorf("%w: ID %d is applied by %s", ErrMigrationNotFound, h.id, opts.From)
+		}
+		checksum, err := migration.Checksum()
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate checksum of migration with ID %d: %w", h.id, err)
+		}
+		imported = append(imported, MigrationResult{
+			ID:        h.id,
+			Name:      migration.Name(),
+			AppliedAt: h.appliedAt,
+			Checksum:  checksum,
+		})
+	}
+
+	if opts.DryRun || len(imported) == 0 {
+		return imported, nil
+	}
+
+	// Some drivers (e.g. ClickHouse) support inserts in a transaction only.
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	for _, r := range imported {
+		err = m.store.InsertImportedMigration(ctx, tx, r.Name, r.ID, r.Checksum, r.AppliedAt)
+		if err != nil {
+			return nil, rollbackTx(tx, fmt.Errorf("failed to import migration with ID %d: %w", r.ID, err))
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	for _, r := range imported {
+		m.logger.InfoContext(ctx, "imported migration", "id", r.ID, "name", r.Name, "from", opts.From,
+			"applied_at", r.AppliedAt)
+	}
+
+	return imported, nil
+}
+
+// historyReader returns the reader of the history of the tool and its version table.
+func (m Migrator) historyReader(opts ImportOptions) (historyReader, string, error) {
+	var read historyReader
+	var table string
+	switch opts.From {
+	case ImportGoose:
+		read, table = m.readGooseHistory, "goose_db_version"
+	case ImportGolangMigrate:
+		read, table = m.readGolangMigrateHistory, "schema_migrations"
+	case ImportSQLMigrate:
+		read, table = readSQLMigrateHistory, "gorp_migrations"
+	case ImportFlyway:
+		read, table = readFlywayHistory, "flyway_schema_history"
+	default:
+		return nil, "", fmt.Errorf("%w: %q", ErrUnknownImportTool, opts.From)
+	}
+
+	if opts.Table != "" {
+		table = opts.Table
+	}
+	return read, table, nil
+}
+
+// readGooseHistory reads goose_db_version, which has a row for every applied and rolled back migration
+// (older goose versions) or for every applied one. The last row of a version tells whether it's applied.
+// Version 0 is the initial row inserted by goose.
+func (m Migrator) readGooseHistory(
+	ctx context.Context, db *sql.DB, table string, _ Migrations,
+) ([]importedMigration, error) {
+	// The ClickHouse table of goose has no id column.
+	order := "id"
+	if m.dialect == store.ClickHouse {
+		order = "tstamp"
+	}
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(`SELECT version_id, is_applied, tstamp FROM %s ORDER BY %s`,
+		table, order))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var id int64
+		var isApplied bool
+		var appliedAt historyTime
+		if err = rows.Scan(&id, &isApplied, &appliedAt); err != nil {
+			return nil, err
+		}
+		switch {
+		case id == 0:
+		case isApplied:
+			applied[id] = appliedAt.Time
+		default:
+			delete(applied, id)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	history := make([]importedMigration, 0, len(applied))
+	for id, appliedAt := range applied {
+		history = append(history, importedMigration{id: id, appliedAt: appliedAt})
+	}
+	return history, nil
+}
+
+// readGolangMigrateHistory reads schema_migrations, which holds the version of the database only.
+// Every migration up to the version is applied, the time it was applied at is unknown.
+func (m Migrator) readGolangMigrateHistory(
+	ctx context.Context, db *sql.DB, table string, migrations Migrations,
+) ([]importedMigration, error) {
+	q := `SELECT version, dirty FROM %s`
+	if m.dialect == store.ClickHouse {
+		// The ClickHouse table of golang-migrate keeps every version, the last one has the highest sequence.
+		q = `SELECT version, dirty FROM %s ORDER BY sequence DESC LIMIT 1`
+	}
+
+	var version int64
+	var dirty bool
+	err := db.QueryRowContext(ctx, fmt.Sprintf(q, table)).Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if dirty {
+		return nil, fmt.Errorf("%w: version %d is dirty", ErrFailedImport, version)
+	}
+
+	now := time.Now().UTC()
+	upTo := filterUpTo(migrations, version)
+	history := make([]importedMigration, 0, len(upTo))
+	for _, migration := range upTo {
+		history = append(history, importedMigration{id: migration.ID(), appliedAt: now})
+	}
+	return history, nil
+}
+
+// readSQLMigrateHistory reads gorp_migrations, which has a row for every applied migration
+// with the file name of the migration as its id.
+func readSQLMigrateHistory(
+	ctx context.Context, db *sql.DB, table string, _ Migrations,
+) ([]importedMigration, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(`SELECT id, applied_at FROM %s`, table))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var history []importedMigration
+	for rows.Next() {
+		var fileName string
+		var appliedAt historyTime
+		if err = rows.Scan(&fileName, &appliedAt); err != nil {
+			return nil, err
+		}
+		id, _, err := ParseMigrationFileName(fileName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ID of migration %s: %w", fileName, err)
+		}
+		history = append(history, importedMigration{id: id, appliedAt: appliedAt.Time})
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// readFlywayHistory reads flyway_schema_history, which has a row for every run of a versioned migration,
+// a baseline row marking migrations up to its version as applied, and rows of undone or deleted versions.
+// Repeatable migrations (without version) are not imported, and versions must be integers, e.g. V3__name.sql.
+func readFlywayHistory(
+	ctx context.Context, db *sql.DB, table string, migrations Migrations,
+) ([]importedMigration, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(
+		`SELECT version, type, success, installed_on FROM %s WHERE version IS NOT NULL ORDER BY installed_rank`,
+		table))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var version, typ string
+		var success bool
+		var appliedAt historyTime
+		if err = rows.Scan(&version, &typ, &success, &appliedAt); err != nil {
+			return nil, err
+		}
+		if typ == "SCHEMA" {
+			continue
+		}
+		id, err := strconv.ParseInt(version, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("version %q can't be used as a migration ID: %w", version, err)
+		}
+		if !success {
+			return nil, fmt.Errorf("%w: version %s failed", ErrFailedImport, version)
+		}
+
+		switch typ {
+		case "BASELINE":
+			for _, migration := range filterUpTo(migrations, id) {
+				applied[migration.ID()] = appliedAt.Time
+			}
+		case "DELETE", "UNDO_SQL", "UNDO_JDBC", "UNDO_SCRIPT":
+			delete(applied, id)
+		default:
+			applied[id] = appliedAt.Time
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	history := make([]importedMigration, 0, len(applied))
+	for id, appliedAt := range applied {
+		history = append(history, importedMigration{id: id, appliedAt: appliedAt})
+	}
+	return history, nil
+}
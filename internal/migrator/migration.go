@@ -2,10 +2,17 @@ package migrator
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
 
 	"github.com/evgodev/migratory/internal/migrator/executor"
+	"github.com/evgodev/migratory/internal/migrator/parser"
+	"github.com/evgodev/migratory/internal/migrator/store"
 )
 
 var (
@@ -13,19 +20,52 @@ var (
 	ErrNilMigrationExecutor = errors.New("migration executors is nil")
 	ErrNilMigrationPreparer = errors.New("migration preparer is nil")
 	ErrNilExecutorContainer = errors.New("migration preparer returned nil executors")
+	ErrNotResumable         = errors.New("migration can't be resumed, its statements are unknown")
 )
 
 type Migrations []Migration
 
+// MergeMigrations merges migrations from several sources (e.g. Go and SQL) into a single list sorted by ID.
+// It returns ErrDuplicatedID if the same ID is found more than once, within a source or across them.
+func MergeMigrations(sources ...Migrations) (Migrations, error) {
+	var size int
+	for _, source := range sources {
+		size += len(source)
+	}
+
+	merged := make(Migrations, 0, size)
+	uniqueIDMap := make(map[int64]string, size)
+	for _, source := range sources {
+		for _, migration := range source {
+			if name, exists := uniqueIDMap[migration.ID()]; exists {
+				return nil, fmt.Errorf("migration ID %d is duplicated (%s, %s): %w",
+					migration.ID(), name, migration.Name(), ErrDuplicatedID)
+			}
+			uniqueIDMap[migration.ID()] = migration.Name()
+			merged = append(merged, migration)
+		}
+	}
+
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].ID() < merged[j].ID()
+	})
+
+	return merged, nil
+}
+
 // Migration represents a database migration with a unique ID, name, and executors for transactional
 // or non-transactional use. This type manages whether a migration is prepared for execution and
 // supports lazy loading (SQL migrations are parsed only during the migration application process).
 type Migration struct {
-	id   int64
-	name string
+	id         int64
+	name       string
+	repeatable bool // A repeatable migration has no ID, see SeekRepeatables.
+	// An irreversible migration can't be rolled back, it's a migration of split files without a down file.
+	irreversible bool
 
 	isPrepared bool
 	preparer   *sqlPreparer
+	parsed     *parser.ParsedMigration // Statements of a prepared SQL migration, nil for Go migrations.
 
 	executors executors
 }
@@ -54,12 +94,31 @@ func NewGoMigrationNoTx(id int64, name string, up, down executor.GoMigrateNoTxFn
 	}
 }
 
-func NewSQLMigration(id int64, name, filePath string) Migration {
+// NewSQLMigration creates a migration of the SQL file, which is parsed lazily in the syntax of the dialect.
+func NewSQLMigration(id int64, name, filePath, dialect string) Migration {
+	return newSQLMigration(id, name, osFS{}, filePath, parserOptions(dialect))
+}
+
+func newSQLMigration(id int64, name string, fsys fs.FS, filePath string, opts parser.Options) Migration {
 	return Migration{
 		id:         id,
 		name:       name,
 		isPrepared: false,
-		preparer:   newSQLPreparer(filePath),
+		preparer:   newSQLPreparer(fsys, filePath, opts),
+	}
+}
+
+// newSplitSQLMigration creates a migration with up and down statements in separate files,
+// the down file path is empty if there is none, then the migration is irreversible.
+func newSplitSQLMigration(
+	id int64, name string, fsys fs.FS, upFilePath, downFilePath string, opts parser.Options,
+) Migration {
+	return Migration{
+		id:           id,
+		name:         name,
+		irreversible: downFilePath == "",
+		isPrepared:   false,
+		preparer:     newSplitSQLPreparer(fsys, upFilePath, downFilePath, opts),
 	}
 }
 
@@ -111,6 +170,46 @@ func (m *Migration) DownDB(ctx context.Context, db *sql.DB) error {
 	return m.executors.ExecutorDB().Down(ctx, db)
 }
 
+// UpDBFrom applies the migration without transaction, skipping the first `from` statements
+// executed by an interrupted run, and calls progress after each statement if the executor supports it.
+func (m *Migration) UpDBFrom(ctx context.Context, db *sql.DB, from int, progress executor.ProgressFn) error {
+	if !m.isPrepared {
+		return ErrMigrationNotPrepared
+	}
+
+	if e, ok := m.executors.ExecutorDB().(ResumableExecutorDB); ok {
+		return e.UpFrom(ctx, db, from, progress)
+	}
+	if from > 0 {
+		return ErrNotResumable
+	}
+
+	return m.UpDB(ctx, db)
+}
+
+// DownDBFrom rolls back the migration without transaction, see UpDBFrom.
+func (m *Migration) DownDBFrom(ctx context.Context, db *sql.DB, from int, progress executor.ProgressFn) error {
+	if !m.isPrepared {
+		return ErrMigrationNotPrepared
+	}
+
+	if e, ok := m.executors.ExecutorDB().(ResumableExecutorDB); ok {
+		return e.DownFrom(ctx, db, from, progress)
+	}
+	if from > 0 {
+		return ErrNotResumable
+	}
+
+	return m.DownDB(ctx, db)
+}
+
+// IsResumable reports whether a prepared migration is run without transaction statement by statement,
+// so an interrupted run can be resumed.
+func (m *Migration) IsResumable() bool {
+	_, ok := m.executors.ExecutorDB().(ResumableExecutorDB)
+	return m.isPrepared && m.executors.useDB && ok
+}
+
 func (m *Migration) ChooseExecutor() (noTx bool, err error) {
 	if err = m.ensureIsPrepared(); err != nil {
 		return false, err
@@ -123,10 +222,58 @@ func (m *Migration) ID() int64 {
 	return m.id
 }
 
+// IsIrreversible reports whether the migration can't be rolled back, as it has no down file.
+func (m *Migration) IsIrreversible() bool {
+	return m.irreversible
+}
+
+// IsRepeatable reports whether the migration is a repeatable one, which has no ID, see SeekRepeatables.
+func (m *Migration) IsRepeatable() bool {
+	return m.repeatable
+}
+
 func (m *Migration) Name() string {
 	return m.name
 }
 
+// Checksum returns the SHA-256 checksum of the parsed up and down statements of a SQL migration,
+// so that edits of an applied file are detected. It's empty for Go migrations, as their statements are unknown.
+func (m *Migration) Checksum() (string, error) {
+	if err := m.ensureIsPrepared(); err != nil {
+		return "", err
+	}
+
+	if m.parsed == nil {
+		return "", nil
+	}
+
+	return statementsChecksum(m.parsed), nil
+}
+
+// ExecutedChecksum returns the SHA-256 checksum of the first n statements of a prepared SQL migration
+// in the direction. It's recorded in the dirty mark, so that an interrupted run is not resumed after
+// the statements it executed were edited. It's empty for Go migrations.
+func (m *Migration) ExecutedChecksum(direction string, n int) string {
+	if m.parsed == nil {
+		return ""
+	}
+
+	statements := m.parsed.UpStatements
+	if direction == store.DirectionDown {
+		statements = m.parsed.DownStatements
+	}
+	if n > len(statements) {
+		return ""
+	}
+
+	h := sha256.New()
+	for _, statement := range statements[:n] {
+		_, _ = h.Write([]byte(statement))
+		_, _ = h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
 func (m *Migration) ensureIsPrepared() error {
 	if m.isPrepared {
 		return nil
@@ -139,18 +286,71 @@ func (m *Migration) ensureIsPrepared() error {
 	return m.prepare()
 }
 
+// HasDDL reports whether a prepared SQL migration contains DDL statements in any direction.
+// It's always false for Go migrations, as their statements are unknown.
+func (m *Migration) HasDDL() bool {
+	if m.parsed == nil {
+		return false
+	}
+
+	for _, statements := range [][]string{m.parsed.UpStatements, m.parsed.DownStatements} {
+		for _, statement := range statements {
+			if parser.IsDDL(statement) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// DisableTransaction switches a prepared SQL migration to the non-transactional executor.
+// Go migrations are left as is, since their functions are bound to the connection type.
+func (m *Migration) DisableTransaction() {
+	if m.parsed == nil {
+		return
+	}
+
+	e := executor.NewSQLExecutorNoTx(m.parsed.UpStatements, m.parsed.DownStatements)
+	m.executors = *newExecutorDBContainer(e)
+}
+
 func (m *Migration) prepare() error {
-	executorController, err := m.preparer.Prepare()
+	parsed, err := m.preparer.Parse()
 	if err != nil {
 		return err
 	}
 
+	executorController := newSQLExecutors(parsed)
 	if executorController == nil {
 		return ErrNilExecutorContainer
 	}
 
+	m.parsed = parsed
 	m.executors = *executorController
 	m.isPrepared = true
 
 	return nil
 }
+
+// statementsChecksum hashes statements rather than the file, so formatting outside of statements
+// (e.g. comment and blank lines) doesn't change the checksum.
+func statementsChecksum(parsed *parser.ParsedMigration) string {
+	h := sha256.New()
+	for _, section := range []struct {
+		direction  string
+		statements []string
+	}{
+		{direction: "up", statements: parsed.UpStatements},
+		{direction: "down", statements: parsed.DownStatements},
+	} {
+		_, _ = h.Write([]byte(section.direction))
+		for _, statement := range section.statements {
+			// The separator keeps statements "a", "b" and "ab" distinct.
+			_, _ = h.Write([]byte{0})
+			_, _ = h.Write([]byte(statement))
+		}
+		_, _ = h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,93 @@
+package migrator
+
+import (
+	"testing"
+
+	"github.com/evgodev/migratory/internal/migrator/parser"
+	"github.com/evgodev/migratory/internal/migrator/store"
+	"github.com/evgodev/migratory/internal/require"
+)
+
+func TestMergeMigrations(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		sources []Migrations
+		want    Migrations
+		wantErr error
+	}{
+		"no sources": {
+			sources: nil,
+			want:    Migrations{},
+			wantErr: nil,
+		},
+		"one source": {
+			sources: []Migrations{
+				{Migration{id: 2}, Migration{id: 1}},
+			},
+			want:    Migrations{Migration{id: 1}, Migration{id: 2}},
+			wantErr: nil,
+		},
+		"interleaved sources": {
+			sources: []Migrations{
+				{Migration{id: 1}, Migration{id: 4}},
+				{Migration{id: 2}, Migration{id: 3}, Migration{id: 5}},
+			},
+			want: Migrations{
+				Migration{id: 1},
+				Migration{id: 2},
+				Migration{id: 3},
+				Migration{id: 4},
+				Migration{id: 5},
+			},
+			wantErr: nil,
+		},
+		"duplicate across sources": {
+			sources: []Migrations{
+				{Migration{id: 1}, Migration{id: 2}},
+				{Migration{id: 2}},
+			},
+			want:    nil,
+			wantErr: ErrDuplicatedID,
+		},
+		"duplicate within source": {
+			sources: []Migrations{
+				{Migration{id: 1}, Migration{id: 1}},
+			},
+			want:    nil,
+			wantErr: ErrDuplicatedID,
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := MergeMigrations(test.sources...)
+			require.ErrorIs(t, err, test.wantErr, "MergeMigrations(...) error")
+			require.Equal(t, got, test.want, "MergeMigrations(...) migrations")
+		})
+	}
+}
+
+func TestExecutedChecksum(t *testing.T) {
+	t.Parallel()
+	migration := Migration{parsed: &parser.ParsedMigration{
+		UpStatements:   []string{"CREATE TABLE a (id INTEGER);", "CREATE TABLE b (id INTEGER);"},
+		DownStatements: []string{"DROP TABLE b;", "DROP TABLE a;"},
+	}}
+	edited := Migration{parsed: &parser.ParsedMigration{
+		UpStatements:   []string{"CREATE TABLE a (id INTEGER);", "CREATE TABLE c (id INTEGER);"},
+		DownStatements: []string{"DROP TABLE c;", "DROP TABLE a;"},
+	}}
+
+	require.Equal(t, migration.ExecutedChecksum(store.DirectionUp, 1), edited.ExecutedChecksum(store.DirectionUp, 1),
+		"checksum of unchanged executed statements")
+	if migration.ExecutedChecksum(store.DirectionUp, 2) == edited.ExecutedChecksum(store.DirectionUp, 2) {
+		t.Fatalf("checksum of edited executed statements must differ")
+	}
+	if migration.ExecutedChecksum(store.DirectionUp, 1) == migration.ExecutedChecksum(store.DirectionDown, 1) {
+		t.Fatalf("checksums of up and down statements must differ")
+	}
+	require.String(t, migration.ExecutedChecksum(store.DirectionUp, 3), "", "checksum of more statements than exist")
+	goMigration := NewGoMigration(1, "go", nil, nil)
+	require.String(t, goMigration.ExecutedChecksum(store.DirectionUp, 0), "", "checksum of Go migration")
+}
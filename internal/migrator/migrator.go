@@ -5,60 +5,337 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"log/slog"
+	"math"
 	"sort"
+	"strings"
 	"time"
 
+	"github.com/evgodev/migratory/internal/migrator/executor"
+	"github.com/evgodev/migratory/internal/migrator/parser"
 	"github.com/evgodev/migratory/internal/migrator/store"
 )
 
 const (
 	Postgres   = store.Postgres
 	ClickHouse = store.ClickHouse
+	MySQL      = store.MySQL
+	SQLite     = store.SQLite
 )
 
 var (
-	ErrDirtyMigrations   = errors.New("dirty migration(s) found (unapplied one with ID less than database version)")
-	ErrUnknownDBVersion  = errors.New("no rows in migrations table, database version is unknown")
-	ErrNothingToRollback = errors.New("no rows in migrations table, nothing to rollback")
+	ErrDirtyMigrations     = errors.New("dirty migration(s) found (unapplied one with ID less than database version)")
+	ErrUnknownDBVersion    = errors.New("no rows in migrations table, database version is unknown")
+	ErrNothingToRollback   = errors.New("no rows in migrations table, nothing to rollback")
+	ErrNoPendingMigrations = errors.New("no pending migrations, the database is up to date")
+	ErrModifiedMigrations  = errors.New("applied migration(s) modified since they were applied")
+	ErrDirtyDatabase       = errors.New("database is dirty, a migration without transaction was interrupted")
+	ErrNotDirty            = errors.New("database is not dirty, nothing to repair")
+	ErrInvalidMigrations   = errors.New("invalid migration(s)")
+	ErrNotEmptyTable       = errors.New("migrations table is not empty")
+	ErrMigrationNotFound   = errors.New("migration not found")
+	ErrAlreadyApplied      = errors.New("migration is already applied")
+	ErrNotApplied          = errors.New("migration is not applied")
+	ErrIrreversible        = errors.New("migration is irreversible, it has no down file")
+	ErrStatementsChanged   = executor.ErrStatementsChanged
+	ErrLockTimeout         = store.ErrLockTimeout
 )
 
+// upLimits restricts the pending migrations applied by up.
+type upLimits struct {
+	version int64 // Maximum ID of migrations to apply.
+	count   int   // Maximum number of migrations to apply, 0 means no limit.
+}
+
+// ParseError describes an error in an SQL migration file with its position.
+type ParseError = parser.ParseError
+
+// RepairAction tells Repair how an interrupted migration was resolved by hand.
+type RepairAction int
+
+const (
+	// RepairApplied records the interrupted migration as applied.
+	RepairApplied RepairAction = iota + 1
+	// RepairRolledBack records the interrupted migration as not applied.
+	RepairRolledBack
+)
+
+// resetVersion is a version lower than any migration ID, rolling back to it rolls back everything.
+const resetVersion int64 = math.MinInt64
+
 type Migrator struct {
-	store *store.Store
+	dialect   string
+	store     *store.Store
+	storeOpts []store.Option
+
+	useLock  bool
+	lockOpts store.LockOptions
+
+	validateChecksums bool
+	preflight         bool
+	plan              *Plan // Filled instead of running migrations, see WithDryRun.
+
+	logger    *slog.Logger
+	hooks     Hooks
+	callbacks *Callbacks
+
+	repeatables Migrations // Repeatable migrations applied by Up, see WithRepeatables.
+}
+
+// Option configures optional Migrator behaviour.
+type Option func(m *Migrator)
+
+// LockOptions configures the migrations lock, see WithLock.
+type LockOptions = store.LockOptions
+
+// WithSchema sets the schema of the migrations table (Postgres only), the schema is created if not exists.
+func WithSchema(schema string) Option {
+	return func(m *Migrator) {
+		m.storeOpts = append(m.storeOpts, store.WithSchema(schema))
+	}
+}
+
+// WithLock makes the Migrator hold a lock while it creates the migrations table and applies or rolls back
+// migrations, so that several processes started at once (e.g. replicas of one service) don't race.
+// Advisory locks are used on Postgres, and a lock table with TTL-based stale lock takeover on other databases.
+func WithLock(opts LockOptions) Option {
+	return func(m *Migrator) {
+		m.useLock = true
+		m.lockOpts = opts
+	}
+}
+
+// WithChecksumValidation makes Up refuse to apply migrations with ErrModifiedMigrations
+// if any applied SQL migration was modified since it was applied.
+func WithChecksumValidation() Option {
+	return func(m *Migrator) {
+		m.validateChecksums = true
+	}
+}
+
+// WithPreflight makes Up prepare (parse) every pending migration before applying any,
+// so that an invalid migration is reported before the ones preceding it are applied.
+func WithPreflight() Option {
+	return func(m *Migrator) {
+		m.preflight = true
+	}
+}
+
+// WithDryRun makes the Migrator fill plan with the migrations it would run, in order, instead of running them.
+// Migrations are neither executed nor recorded in the migrations table, and the lock is not taken.
+// The database is not changed at all: the tables of the Migrator are neither created nor upgraded, migrations
+// are planned against the ones that exist. A migration interrupted in the planned direction is planned
+// with the statements left to execute only. Baseline returns the number of migrations it would mark,
+// MarkApplied, MarkUnapplied and Repair return the migration they would record.
+func WithDryRun(plan *Plan) Option {
+	return func(m *Migrator) {
+		m.plan = plan
+	}
+}
+
+// WithLogger makes the Migrator log creation of the migrations table, start and finish of every migration,
+// and changes of the migrations table made by hand (baseline, mark, repair). Statements are logged at debug level.
+// Nothing is logged by default.
+func WithLogger(logger *slog.Logger) Option {
+	return func(m *Migrator) {
+		m.logger = logger
+	}
+}
+
+// Plan lists the migrations a dry run would apply or roll back, in the order they would be run.
+type Plan struct {
+	Migrations []PlannedMigration
+}
+
+// PlannedMigration is a migration a dry run would apply or roll back.
+type PlannedMigration struct {
+	ID        int64
+	Name      string
+	Direction string // "up" or "down".
+	NoTx      bool   // The migration would be run without a transaction.
+	// Repeatable migrations have no ID, they are applied after versioned ones, see WithRepeatables.
+	Repeatable bool
+	// Statements of a SQL migration in the order they would be executed, empty for Go migrations.
+	Statements []string
+}
+
+// add plans the migration, from is the number of statements executed by its interrupted run.
+func (p *Plan) add(migration *Migration, direction string, noTx bool, from int) {
+	planned := PlannedMigration{
+		ID:         migration.ID(),
+		Name:       migration.Name(),
+		Direction:  direction,
+		NoTx:       noTx,
+		Repeatable: migration.IsRepeatable(),
+	}
+	if migration.parsed != nil {
+		planned.Statements = migration.parsed.UpStatements
+		if direction == store.DirectionDown {
+			planned.Statements = migration.parsed.DownStatements
+		}
+		planned.Statements = planned.Statements[from:]
+	}
+	p.Migrations = append(p.Migrations, planned)
 }
 
 type MigrationResult struct {
 	ID        int64
 	Name      string
 	AppliedAt time.Time
+	Checksum  string // Checksum of the statements recorded when the migration was applied.
+	Modified  bool   // The applied migration was modified since it was applied.
+	Dirty     bool   // The migration without transaction was interrupted, see Repair.
+	Baselined bool   // The migration was marked as applied by Baseline without running it.
 }
 
-func New(ctx context.Context, db *sql.DB, dialect, tableName string) (*Migrator, error) {
-	s, err := store.New(dialect, tableName)
+func New(ctx context.Context, db *sql.DB, dialect, tableName string, opts ...Option) (*Migrator, error) {
+	m, err := newMigrator(dialect, tableName, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.plan != nil {
+		if m.store, err = m.store.Inspect(ctx, db); err != nil {
+			return nil, fmt.Errorf("failed to inspect migrations table: %w", err)
+		}
+		return m, nil
+	}
+
+	err = m.withLock(ctx, db, func() error {
+		return m.ensureMigrationsTable(ctx, db)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// newMigrator creates a Migrator without touching the database.
+func newMigrator(dialect, tableName string, opts []Option) (*Migrator, error) {
+	m := &Migrator{dialect: dialect}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.logger == nil {
+		m.logger = slog.New(slog.DiscardHandler)
+	}
+	if m.callbacks != nil {
+		m.hooks = joinHooks(m.callbacks.hooks(), m.hooks)
+	}
+
+	s, err := store.New(dialect, tableName, m.storeOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get store: %w", err)
 	}
+	m.store = s
 
-	exists, err := s.MigrationsTableExists(ctx, db)
+	return m, nil
+}
+
+func (m Migrator) ensureMigrationsTable(ctx context.Context, db *sql.DB) error {
+	exists, err := m.store.MigrationsTableExists(ctx, db)
 	if err != nil {
-		return nil, fmt.Errorf("failed to check if migrations table exists: %w", err)
+		return fmt.Errorf("failed to check if migrations table exists: %w", err)
 	}
 
 	if !exists {
-		if err = s.CreateMigrationsTable(ctx, db); err != nil {
-			return nil, fmt.Errorf("failed to create migrations table: %w", err)
+		if err = m.store.CreateMigrationsTable(ctx, db); err != nil {
+			return fmt.Errorf("failed to create migrations table: %w", err)
 		}
+		m.logger.InfoContext(ctx, "created migrations table", "table", m.store.TableName())
+	} else if err = m.store.UpgradeMigrationsTable(ctx, db); err != nil {
+		return fmt.Errorf("failed to upgrade migrations table: %w", err)
+	}
+
+	if err = m.store.CreateDirtyTable(ctx, db); err != nil {
+		return fmt.Errorf("failed to create dirty marks table: %w", err)
+	}
+
+	if err = m.store.CreateRepeatableTable(ctx, db); err != nil {
+		return fmt.Errorf("failed to create repeatable migrations table: %w", err)
 	}
 
-	return &Migrator{s}, nil
+	return nil
+}
+
+// withLock runs fn holding the migrations lock if locking is enabled.
+func (m Migrator) withLock(ctx context.Context, db *sql.DB, fn func() error) (err error) {
+	// A dry run changes nothing, so it doesn't need to wait for other runs.
+	if !m.useLock || m.plan != nil {
+		return fn()
+	}
+
+	locker, err := m.store.NewLocker(db, m.lockOpts)
+	if err != nil {
+		return fmt.Errorf("failed to create locker: %w", err)
+	}
+
+	if err = locker.Lock(ctx); err != nil {
+		return fmt.Errorf("failed to acquire migrations lock: %w", err)
+	}
+
+	defer func() {
+		// The lock must be released even if ctx is canceled.
+		if unlockErr := locker.Unlock(context.WithoutCancel(ctx)); unlockErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to release migrations lock: %w", unlockErr))
+		}
+	}()
+
+	return fn()
 }
 
 func (m Migrator) Up(ctx context.Context, migrations Migrations, db *sql.DB, force bool) (n int, err error) {
+	return m.upWithLimits(ctx, migrations, db, force, upLimits{version: math.MaxInt64})
+}
+
+// UpTo applies pending migrations with ID less than or equal to version.
+func (m Migrator) UpTo(ctx context.Context, migrations Migrations, db *sql.DB, force bool, version int64) (int, error) {
+	return m.upWithLimits(ctx, migrations, db, force, upLimits{version: version})
+}
+
+// UpLimit applies at most limit pending migrations in order.
+func (m Migrator) UpLimit(ctx context.Context, migrations Migrations, db *sql.DB, force bool, limit int) (int, error) {
+	return m.upWithLimits(ctx, migrations, db, force, upLimits{version: math.MaxInt64, count: limit})
+}
+
+// UpByOne applies the next pending migration. It returns ErrNoPendingMigrations if there is none.
+func (m Migrator) UpByOne(ctx context.Context, migrations Migrations, db *sql.DB, force bool) error {
+	n, err := m.UpLimit(ctx, migrations, db, force, 1)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNoPendingMigrations
+	}
+	return nil
+}
+
+func (m Migrator) upWithLimits(
+	ctx context.Context, migrations Migrations, db *sql.DB, force bool, limits upLimits,
+) (n int, err error) {
+	err = m.withLock(ctx, db, func() error {
+		n, err = m.up(ctx, migrations, db, force, limits)
+		return err
+	})
+	return n, err
+}
+
+func (m Migrator) up(
+	ctx context.Context, migrations Migrations, db *sql.DB, force bool, limits upLimits,
+) (n int, err error) {
 	appliedMigrations, err := m.getAppliedMigrations(ctx, db)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get applied migrations: %w", err)
 	}
 
-	missingMigrations, dirty := findMissingMigrations(migrations, appliedMigrations)
+	if m.validateChecksums {
+		if err = validateChecksums(migrations, appliedMigrations); err != nil {
+			return 0, err
+		}
+	}
+
+	missingMigrations, dirty := findMissingMigrations(filterUpTo(migrations, limits.version), appliedMigrations)
 	if !force && dirty {
 		return 0, ErrDirtyMigrations
 	}
@@ -67,45 +344,235 @@ func (m Migrator) Up(ctx context.Context, migrations Migrations, db *sql.DB, for
 		return missingMigrations[i].ID() < missingMigrations[j].ID()
 	})
 
-	var appliedCount int
-	for _, migration := range missingMigrations {
-		if err = m.upOne(ctx, migration, db); err != nil {
-			return appliedCount, fmt.Errorf("failed to up migration with ID %d: %w", migration.ID(), err)
+	if limits.count > 0 && len(missingMigrations) > limits.count {
+		missingMigrations = missingMigrations[:limits.count]
+	}
+
+	if m.preflight {
+		if err = PrepareMigrations(missingMigrations); err != nil {
+			return 0, err
+		}
+	}
+
+	var next *Migration
+	if len(missingMigrations) > 0 {
+		next = &missingMigrations[0]
+	}
+	if err = m.checkDirty(ctx, db, next, store.DirectionUp); err != nil {
+		return 0, err
+	}
+
+	// Repeatable migrations are applied by full runs only, after all versioned ones.
+	var repeatables Migrations
+	if limits.version == math.MaxInt64 && limits.count == 0 {
+		if repeatables, err = m.pendingRepeatables(ctx, db); err != nil {
+			return 0, err
 		}
-		appliedCount++
 	}
 
-	return appliedCount, nil
+	if len(missingMigrations) == 0 && len(repeatables) == 0 {
+		return 0, nil
+	}
+
+	var appliedCount int
+	err = m.runAll(ctx, db, store.DirectionUp, func() error {
+		for _, migration := range missingMigrations {
+			if err := m.upOne(ctx, migration, db); err != nil {
+				if isRecorded(err) {
+					appliedCount++
+					return fmt.Errorf("migration with ID %d is applied, but %w", migration.ID(), err)
+				}
+				return fmt.Errorf("failed to up migration with ID %d: %w", migration.ID(), err)
+			}
+			appliedCount++
+		}
+		for _, repeatable := range repeatables {
+			if err := m.upRepeatable(ctx, repeatable, db); err != nil {
+				if isRecorded(err) {
+					appliedCount++
+					return fmt.Errorf("repeatable migration %s is applied, but %w", repeatable.Name(), err)
+				}
+				return fmt.Errorf("failed to up repeatable migration %s: %w", repeatable.Name(), err)
+			}
+			appliedCount++
+		}
+		return nil
+	})
+
+	return appliedCount, err
 }
 
 func (m Migrator) Down(ctx context.Context, migrations Migrations, db *sql.DB, redo bool) error {
+	return m.withLock(ctx, db, func() error {
+		return m.down(ctx, migrations, db, redo)
+	})
+}
+
+func (m Migrator) down(ctx context.Context, migrations Migrations, db *sql.DB, redo bool) error {
 	last, err := m.getLastMigration(ctx, migrations, db)
-	if err != nil {
-		if errors.Is(err, store.ErrNoRows) {
-			return ErrNothingToRollback
-		}
+	if err != nil && !errors.Is(err, store.ErrNoRows) {
 		return fmt.Errorf("failed to find last migration: %w", err)
 	}
 
-	if err = m.downOne(ctx, last, db); err != nil {
-		return fmt.Errorf("failed to rollback last migration with ID %d: %w", last.ID(), err)
+	if dirtyErr := m.checkDirty(ctx, db, last, store.DirectionDown); dirtyErr != nil {
+		return dirtyErr
+	}
+	if err != nil {
+		return ErrNothingToRollback
+	}
+	if last.IsIrreversible() {
+		return fmt.Errorf("last migration with ID %d: %w", last.ID(), ErrIrreversible)
 	}
 
-	if redo {
-		if err = m.upOne(ctx, *last, db); err != nil {
+	// Redo is a single run: BeforeAll and AfterAll are called once around the rollback and the apply.
+	return m.runAll(ctx, db, store.DirectionDown, func() error {
+		if err := m.downOne(ctx, last, db); err != nil {
+			if isRecorded(err) {
+				return fmt.Errorf("last migration with ID %d is rolled back, but %w", last.ID(), err)
+			}
+			return fmt.Errorf("failed to rollback last migration with ID %d: %w", last.ID(), err)
+		}
+		if !redo {
+			return nil
+		}
+
+		if err := m.upOne(ctx, *last, db); err != nil {
+			if isRecorded(err) {
+				return fmt.Errorf("last migration with ID %d is applied again, but %w", last.ID(), err)
+			}
 			return fmt.Errorf("failed to apply last migration with ID %d: %w", last.ID(), err)
 		}
+		return nil
+	})
+}
+
+// DownTo rolls back applied migrations with ID greater than version, starting from the last one.
+// It returns the number of rolled back migrations.
+func (m Migrator) DownTo(ctx context.Context, migrations Migrations, db *sql.DB, version int64) (n int, err error) {
+	err = m.withLock(ctx, db, func() error {
+		n, err = m.downTo(ctx, migrations, db, version)
+		return err
+	})
+	return n, err
+}
+
+// Reset rolls back all applied migrations, starting from the last one.
+func (m Migrator) Reset(ctx context.Context, migrations Migrations, db *sql.DB) (int, error) {
+	return m.DownTo(ctx, migrations, db, resetVersion)
+}
+
+func (m Migrator) downTo(ctx context.Context, migrations Migrations, db *sql.DB, version int64) (int, error) {
+	last, err := m.getLastMigration(ctx, migrations, db)
+	if err != nil && !errors.Is(err, store.ErrNoRows) {
+		return 0, fmt.Errorf("failed to find last migration: %w", err)
+	}
+
+	if err = m.checkDirty(ctx, db, last, store.DirectionDown); err != nil {
+		return 0, err
+	}
+	if err = m.checkReversible(ctx, migrations, db, version); err != nil {
+		return 0, err
+	}
+
+	if m.plan != nil {
+		return m.planDownTo(ctx, migrations, db, version)
+	}
+
+	if last == nil || last.ID() <= version {
+		return 0, nil
+	}
+
+	var rolledBackCount int
+	err = m.runAll(ctx, db, store.DirectionDown, func() error {
+		for {
+			last, err := m.getLastMigration(ctx, migrations, db)
+			if err != nil {
+				if errors.Is(err, store.ErrNoRows) {
+					return nil
+				}
+				return fmt.Errorf("failed to find last migration: %w", err)
+			}
+
+			if last.ID() <= version {
+				return nil
+			}
+
+			if err = m.downOne(ctx, last, db); err != nil {
+				if isRecorded(err) {
+					rolledBackCount++
+					return fmt.Errorf("migration with ID %d is rolled back, but %w", last.ID(), err)
+				}
+				return fmt.Errorf("failed to rollback migration with ID %d: %w", last.ID(), err)
+			}
+			rolledBackCount++
+		}
+	})
+
+	return rolledBackCount, err
+}
+
+// checkReversible returns ErrIrreversible if an applied migration with ID greater than version
+// can't be rolled back, so nothing is rolled back before it.
+func (m Migrator) checkReversible(ctx context.Context, migrations Migrations, db *sql.DB, version int64) error {
+	appliedMigrations, err := m.getAppliedMigrations(ctx, db)
+	if err != nil {
+		return fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+
+	for _, applied := range appliedMigrations {
+		if applied.ID <= version {
+			continue
+		}
+		// A migration not found is reported when its turn comes.
+		if migration, err := findMigration(migrations, applied.ID); err == nil && migration.IsIrreversible() {
+			return fmt.Errorf("migration with ID %d: %w", applied.ID, ErrIrreversible)
+		}
 	}
 
 	return nil
 }
 
+// planDownTo plans rolling back applied migrations with ID greater than version. Unlike downTo,
+// it can't take the last migration from the table after every rollback, as nothing is deleted in a dry run.
+func (m Migrator) planDownTo(ctx context.Context, migrations Migrations, db *sql.DB, version int64) (int, error) {
+	appliedMigrations, err := m.getAppliedMigrations(ctx, db)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+
+	sort.Slice(appliedMigrations, func(i, j int) bool {
+		return appliedMigrations[i].ID > appliedMigrations[j].ID
+	})
+
+	var plannedCount int
+	for _, applied := range appliedMigrations {
+		if applied.ID <= version {
+			break
+		}
+
+		migration, err := findMigration(migrations, applied.ID)
+		if err != nil {
+			return plannedCount, err
+		}
+		if err = m.downOne(ctx, migration, db); err != nil {
+			return plannedCount, fmt.Errorf("failed to rollback migration with ID %d: %w", applied.ID, err)
+		}
+		plannedCount++
+	}
+
+	return plannedCount, nil
+}
+
 func (m Migrator) GetStatus(ctx context.Context, migrations Migrations, db *sql.DB) ([]MigrationResult, error) {
 	appliedMigrations, err := m.getAppliedMigrations(ctx, db)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get applied migrations: %w", err)
 	}
 
+	if err = markModified(migrations, appliedMigrations); err != nil {
+		return nil, err
+	}
+
 	missingMigrations, _ := findMissingMigrations(migrations, appliedMigrations)
 
 	results := make([]MigrationResult, 0, len(appliedMigrations)+len(missingMigrations))
@@ -118,6 +585,16 @@ func (m Migrator) GetStatus(ctx context.Context, migrations Migrations, db *sql.
 		})
 	}
 
+	mark, err := m.store.SelectDirtyMark(ctx, db)
+	switch {
+	case err == nil:
+		for i := range results {
+			results[i].Dirty = results[i].ID == mark.ID
+		}
+	case !errors.Is(err, store.ErrNoRows):
+		return nil, fmt.Errorf("failed to select dirty mark: %w", err)
+	}
+
 	sort.Slice(results, func(i, j int) bool {
 		return results[i].ID < results[j].ID
 	})
@@ -125,6 +602,267 @@ func (m Migrator) GetStatus(ctx context.Context, migrations Migrations, db *sql.
 	return results, nil
 }
 
+// Validate checks that every migration, including repeatable ones, and callback file can be prepared
+// and that applied SQL migrations were not modified since they were applied. It returns ErrInvalidMigrations
+// with all parse errors, or ErrModifiedMigrations listing the modified migrations.
+func (m Migrator) Validate(ctx context.Context, migrations Migrations, db *sql.DB) error {
+	all := make(Migrations, 0, len(migrations)+len(m.repeatables))
+	all = append(append(all, migrations...), m.repeatables...)
+	if err := PrepareMigrations(all); err != nil {
+		return err
+	}
+	if err := m.callbacks.Parse(); err != nil {
+		return err
+	}
+
+	appliedMigrations, err := m.getAppliedMigrations(ctx, db)
+	if err != nil {
+		return fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+
+	return validateChecksums(migrations, appliedMigrations)
+}
+
+// Baseline marks migrations with ID less than or equal to version as applied without running them,
+// to adopt a database whose schema was created before migrations were used. The migrations are recorded
+// as baselined with their checksums. It returns ErrNotEmptyTable if the migrations table has rows,
+// unless force is set, then only migrations not applied yet are marked. It returns the number of marked ones.
+func (m Migrator) Baseline(
+	ctx context.Context, migrations Migrations, db *sql.DB, version int64, force bool,
+) (n int, err error) {
+	err = m.withLock(ctx, db, func() error {
+		n, err = m.baseline(ctx, migrations, db, version, force)
+		return err
+	})
+	return n, err
+}
+
+func (m Migrator) baseline(
+	ctx context.Context, migrations Migrations, db *sql.DB, version int64, force bool,
+) (int, error) {
+	appliedMigrations, err := m.getAppliedMigrations(ctx, db)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+	if len(appliedMigrations) > 0 && !force {
+		return 0, fmt.Errorf("%w: %d migration(s) recorded", ErrNotEmptyTable, len(appliedMigrations))
+	}
+
+	missingMigrations, _ := findMissingMigrations(filterUpTo(migrations, version), appliedMigrations)
+	if err = PrepareMigrations(missingMigrations); err != nil {
+		return 0, err
+	}
+	if m.plan != nil {
+		return len(missingMigrations), nil
+	}
+
+	// Some drivers (e.g. ClickHouse) support inserts in a transaction only.
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	for _, migration := range missingMigrations {
+		checksum, err := migration.Checksum()
+		if err == nil {
+			err = m.store.InsertBaselinedMigration(ctx, tx, migration.Name(), migration.ID(), checksum)
+		}
+		if err != nil {
+			if txErr := tx.Rollback(); txErr != nil {
+				return 0, fmt.Errorf("failed to baseline migration with ID %d and rollback transaction: %w; %w",
+					migration.ID(), err, txErr)
+			}
+			return 0, fmt.Errorf("failed to baseline migration with ID %d: %w", migration.ID(), err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	for _, migration := range missingMigrations {
+		m.logger.InfoContext(ctx, "marked migration as baselined", "id", migration.ID(), "name", migration.Name())
+	}
+
+	return len(missingMigrations), nil
+}
+
+// MarkApplied records the migration with the ID as applied without running it, e.g. when it was applied by hand.
+// It returns ErrMigrationNotFound if there is no such migration, and ErrAlreadyApplied if it's applied.
+func (m Migrator) MarkApplied(
+	ctx context.Context, migrations Migrations, db *sql.DB, id int64,
+) (marked MigrationResult, err error) {
+	err = m.withLock(ctx, db, func() error {
+		marked, err = m.mark(ctx, migrations, db, id, true)
+		return err
+	})
+	return marked, err
+}
+
+// MarkUnapplied deletes the record of the applied migration with the ID without rolling it back,
+// e.g. when it was reverted by hand. It returns ErrMigrationNotFound if there is no such migration,
+// and ErrNotApplied if it's not applied.
+func (m Migrator) MarkUnapplied(
+	ctx context.Context, migrations Migrations, db *sql.DB, id int64,
+) (marked MigrationResult, err error) {
+	err = m.withLock(ctx, db, func() error {
+		marked, err = m.mark(ctx, migrations, db, id, false)
+		return err
+	})
+	return marked, err
+}
+
+func (m Migrator) mark(
+	ctx context.Context, migrations Migrations, db *sql.DB, id int64, applied bool,
+) (MigrationResult, error) {
+	var migration *Migration
+	for i := range migrations {
+		if migrations[i].ID() == id {
+			migration = &migrations[i]
+			break
+		}
+	}
+	if migration == nil {
+		return MigrationResult{}, fmt.Errorf("%w: ID %d", ErrMigrationNotFound, id)
+	}
+	marked := MigrationResult{ID: migration.ID(), Name: migration.Name()}
+
+	appliedMigrations, err := m.getAppliedMigrations(ctx, db)
+	if err != nil {
+		return MigrationResult{}, fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+	isApplied := false
+	for _, r := range appliedMigrations {
+		isApplied = isApplied || r.ID == id
+	}
+
+	switch {
+	case applied && isApplied:
+		return MigrationResult{}, fmt.Errorf("%w: ID %d", ErrAlreadyApplied, id)
+	case !applied && !isApplied:
+		return MigrationResult{}, fmt.Errorf("%w: ID %d", ErrNotApplied, id)
+	case m.plan != nil:
+		return marked, nil
+	case !applied:
+		if err = m.store.DeleteMigration(ctx, db, id); err != nil {
+			return MigrationResult{}, fmt.Errorf("failed to delete migration from table: %w", err)
+		}
+		m.logger.InfoContext(ctx, "marked migration as not applied", "id", id, "name", migration.Name())
+		return marked, nil
+	}
+
+	checksum, err := migration.Checksum()
+	if err != nil {
+		return MigrationResult{}, fmt.Errorf("failed to calculate checksum: %w", err)
+	}
+
+	if err = m.store.InsertMigration(ctx, db, migration.Name(), id, checksum); err != nil {
+		return MigrationResult{}, fmt.Errorf("failed to insert migration in table: %w", err)
+	}
+	m.logger.InfoContext(ctx, "marked migration as applied", "id", id, "name", migration.Name())
+
+	return marked, nil
+}
+
+// Repair resolves an interrupted migration without transaction after the database was fixed by hand:
+// the migration is recorded as applied or rolled back according to action, and the dirty mark is removed.
+// It returns ErrNotDirty if no migration was interrupted.
+func (m Migrator) Repair(
+	ctx context.Context, migrations Migrations, db *sql.DB, action RepairAction,
+) (repaired MigrationResult, err error) {
+	err = m.withLock(ctx, db, func() error {
+		repaired, err = m.repair(ctx, migrations, db, action)
+		return err
+	})
+	return repaired, err
+}
+
+func (m Migrator) repair(
+	ctx context.Context, migrations Migrations, db *sql.DB, action RepairAction,
+) (MigrationResult, error) {
+	mark, err := m.store.SelectDirtyMark(ctx, db)
+	if err != nil {
+		if errors.Is(err, store.ErrNoRows) {
+			return MigrationResult{}, ErrNotDirty
+		}
+		return MigrationResult{}, fmt.Errorf("failed to select dirty mark: %w", err)
+	}
+
+	appliedMigrations, err := m.getAppliedMigrations(ctx, db)
+	if err != nil {
+		return MigrationResult{}, fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+	var isApplied bool
+	for _, applied := range appliedMigrations {
+		if applied.ID == mark.ID {
+			isApplied = true
+		}
+	}
+
+	if action != RepairApplied && action != RepairRolledBack {
+		return MigrationResult{}, fmt.Errorf("unknown repair action %d", action)
+	}
+	if m.plan != nil {
+		return MigrationResult{ID: mark.ID, Name: mark.Name}, nil
+	}
+
+	switch action {
+	case RepairApplied:
+		if !isApplied {
+			var checksum string
+			if checksum, err = findChecksum(migrations, mark.ID); err != nil {
+				return MigrationResult{}, err
+			}
+			if err = m.store.InsertMigration(ctx, db, mark.Name, mark.ID, checksum); err != nil {
+				return MigrationResult{}, fmt.Errorf("failed to insert migration in table: %w", err)
+			}
+		}
+	case RepairRolledBack:
+		if isApplied {
+			if err = m.store.DeleteMigration(ctx, db, mark.ID); err != nil {
+				return MigrationResult{}, fmt.Errorf("failed to delete migration from table: %w", err)
+			}
+		}
+	}
+
+	if err = m.store.DeleteDirtyMarks(ctx, db, mark.ID); err != nil {
+		return MigrationResult{}, fmt.Errorf("failed to delete dirty mark: %w", err)
+	}
+	m.logger.InfoContext(ctx, "repaired interrupted migration", "id", mark.ID, "name", mark.Name,
+		"direction", mark.Direction, "applied", action == RepairApplied)
+
+	return MigrationResult{ID: mark.ID, Name: mark.Name}, nil
+}
+
+// checkDirty returns ErrDirtyDatabase if a migration without transaction was interrupted,
+// the database may be partly migrated and must be fixed by hand before running migrations.
+// The only exception is a SQL migration interrupted in the same direction that is run next (may be nil)
+// and is still run without transaction, it's resumed from the statement that failed, see resumeFrom.
+func (m Migrator) checkDirty(ctx context.Context, db *sql.DB, next *Migration, direction string) error {
+	mark, err := m.store.SelectDirtyMark(ctx, db)
+	if err != nil {
+		if errors.Is(err, store.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("failed to select dirty mark: %w", err)
+	}
+
+	if next != nil && next.ID() == mark.ID && direction == mark.Direction {
+		migration := *next
+		if _, err = m.chooseExecutor(&migration); err != nil {
+			return err
+		}
+		if migration.IsResumable() {
+			_, err = resumeFrom(&migration, mark)
+			return err
+		}
+	}
+
+	return fmt.Errorf("%w: %s of migration with ID %d (%s) started at %s was not finished, "+
+		"fix the database and run repair", ErrDirtyDatabase, mark.Direction, mark.ID, mark.Name,
+		mark.CreatedAt.Format(time.DateTime))
+}
+
 func (m Migrator) GetDBVersion(ctx context.Context, db *sql.DB) (int64, error) {
 	lastVersion, err := m.store.SelectLastID(ctx, db)
 	if err != nil {
@@ -149,31 +887,76 @@ func (m Migrator) getAppliedMigrations(ctx context.Context, db *sql.DB) ([]Migra
 			ID:        migration.ID,
 			Name:      migration.Name,
 			AppliedAt: migration.AppliedAt,
+			Checksum:  migration.Checksum,
+			Baselined: migration.Baselined,
 		})
 	}
 
 	return appliedMigrations, nil
 }
 
+// chooseExecutor prepares the migration and reports whether it must be run without a transaction.
+// Databases like MySQL implicitly commit a transaction on DDL statements, so a transaction can't make
+// a SQL migration with DDL atomic, and such migrations are run without one.
+func (m Migrator) chooseExecutor(migration *Migration) (noTx bool, err error) {
+	noTx, err = migration.ChooseExecutor()
+	if err != nil {
+		return false, fmt.Errorf("failed to migration.ChooseExecutor(): %w", err)
+	}
+
+	if !noTx && m.commitsDDL(migration) {
+		migration.DisableTransaction()
+		return true, nil
+	}
+
+	return noTx, nil
+}
+
+// commitsDDL reports whether the transactional SQL migration has DDL statements committed implicitly
+// by the database, so chooseExecutor runs it without transaction.
+func (m Migrator) commitsDDL(migration *Migration) bool {
+	parsed := migration.parsed
+	if parsed == nil || parsed.DisableTransactionUp || parsed.DisableTransactionDown {
+		return false
+	}
+
+	return m.store.CommitsDDL() && migration.HasDDL()
+}
+
 func (m Migrator) upOne(ctx context.Context, migration Migration, db *sql.DB) error {
-	noTx, err := migration.ChooseExecutor()
+	noTx, err := m.chooseExecutor(&migration)
 	if err != nil {
-		return fmt.Errorf("failed to migration.ChooseExecutor(): %w", err)
+		return err
 	}
 
-	if noTx {
-		return m.upNoTx(ctx, migration, db)
+	if m.plan != nil {
+		return m.planMigration(ctx, &migration, db, store.DirectionUp, noTx)
 	}
 
-	return m.upTx(ctx, migration, db)
+	return m.run(ctx, &migration, db, store.DirectionUp, noTx, func(ctx context.Context) error {
+		if noTx {
+			return m.upNoTx(ctx, migration, db)
+		}
+		return m.upTx(ctx, migration, db)
+	})
 }
 
 func (m Migrator) upTx(ctx context.Context, migration Migration, db *sql.DB) error {
+	checksum, err := migration.Checksum()
+	if err != nil {
+		return fmt.Errorf("failed to calculate checksum: %w", err)
+	}
+
 	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 
+	info := newHookInfo(&migration, store.DirectionUp, db, tx)
+	if err = callHook(ctx, m.hooks.BeforeEach, "before each", info); err != nil {
+		return rollbackTx(tx, err)
+	}
+
 	if err = migration.UpTx(ctx, tx); err != nil {
 		if txErr := tx.Rollback(); txErr != nil {
 			return fmt.Errorf("failed to up migration and rollback transaction: %w; %w", err, txErr)
@@ -181,13 +964,17 @@ func (m Migrator) upTx(ctx context.Context, migration Migration, db *sql.DB) err
 		return fmt.Errorf("failed to up migration: %w", err)
 	}
 
-	if err = m.store.InsertMigration(ctx, tx, migration.Name(), migration.ID()); err != nil {
+	if err = m.store.InsertMigration(ctx, tx, migration.Name(), migration.ID(), checksum); err != nil {
 		if txErr := tx.Rollback(); txErr != nil {
 			return fmt.Errorf("failed to insert migration in table and rollback transaction: %w; %w", err, txErr)
 		}
 		return fmt.Errorf("failed to insert migration in table: %w", err)
 	}
 
+	if err = callHook(ctx, m.hooks.AfterEach, "after each", info); err != nil {
+		return rollbackTx(tx, err)
+	}
+
 	if err = tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
@@ -196,28 +983,88 @@ func (m Migrator) upTx(ctx context.Context, migration Migration, db *sql.DB) err
 }
 
 func (m Migrator) upNoTx(ctx context.Context, migration Migration, db *sql.DB) error {
-	if err := migration.UpDB(ctx, db); err != nil {
+	checksum, err := migration.Checksum()
+	if err != nil {
+		return fmt.Errorf("failed to calculate checksum: %w", err)
+	}
+
+	info := newHookInfo(&migration, store.DirectionUp, db, nil)
+	if err = callHook(ctx, m.hooks.BeforeEach, "before each", info); err != nil {
+		return err
+	}
+
+	from, err := m.startNoTx(ctx, &migration, db, store.DirectionUp)
+	if err != nil {
+		return err
+	}
+
+	if err = migration.UpDBFrom(ctx, db, from, m.progressFn(&migration, db, store.DirectionUp)); err != nil {
 		return fmt.Errorf("failed to up migration: %w", err)
 	}
 
-	if err := m.store.InsertMigration(ctx, db, migration.Name(), migration.ID()); err != nil {
+	if err = m.store.InsertMigration(ctx, db, migration.Name(), migration.ID(), checksum); err != nil {
 		return fmt.Errorf("failed to insert migration in table: %w", err)
 	}
 
-	return nil
+	if err = m.store.DeleteDirtyMarks(ctx, db, migration.ID()); err != nil {
+		return fmt.Errorf("failed to delete dirty mark: %w", err)
+	}
+
+	return callAfterRecorded(ctx, m.hooks.AfterEach, info)
 }
 
 func (m Migrator) downOne(ctx context.Context, migration *Migration, db *sql.DB) error {
-	noTx, err := migration.ChooseExecutor()
+	noTx, err := m.chooseExecutor(migration)
 	if err != nil {
-		return fmt.Errorf("failed to migration.ChooseExecutor(): %w", err)
+		return err
 	}
 
-	if noTx {
-		return m.downNoTx(ctx, migration, db)
+	if m.plan != nil {
+		return m.planMigration(ctx, migration, db, store.DirectionDown, noTx)
 	}
 
-	return m.downTx(ctx, migration, db)
+	return m.run(ctx, migration, db, store.DirectionDown, noTx, func(ctx context.Context) error {
+		if noTx {
+			return m.downNoTx(ctx, migration, db)
+		}
+		return m.downTx(ctx, migration, db)
+	})
+}
+
+// run calls fn logging the start and the finish of the migration run with its duration.
+// Statements executed by fn are logged at debug level. The OnError hook is called if fn fails.
+func (m Migrator) run(
+	ctx context.Context, migration *Migration, db *sql.DB, direction string, noTx bool,
+	fn func(ctx context.Context) error,
+) error {
+	attrs := []any{"id", migration.ID()}
+	if migration.IsRepeatable() {
+		attrs = []any{"repeatable", true}
+	}
+	logger := m.logger.With(append(attrs, "name", migration.Name(), "direction", direction, "transaction", !noTx)...)
+
+	if noTx && m.commitsDDL(migration) {
+		logger.WarnContext(ctx, "migration with DDL statements is run without transaction, "+
+			"as the database commits DDL implicitly")
+	}
+	logger.InfoContext(ctx, "migration started")
+	started := time.Now()
+
+	if err := fn(executor.WithLogger(ctx, logger)); err != nil {
+		if isRecorded(err) {
+			logger.InfoContext(ctx, "migration finished", "duration", time.Since(started))
+			logger.ErrorContext(ctx, "after each hook failed", "error", err)
+		} else {
+			logger.ErrorContext(ctx, "migration failed", "duration", time.Since(started), "error", err)
+		}
+		if m.hooks.OnError != nil {
+			m.hooks.OnError(ctx, newHookInfo(migration, direction, db, nil), err)
+		}
+		return err
+	}
+
+	logger.InfoContext(ctx, "migration finished", "duration", time.Since(started))
+	return nil
 }
 
 func (m Migrator) downTx(ctx context.Context, migration *Migration, db *sql.DB) error {
@@ -226,6 +1073,11 @@ func (m Migrator) downTx(ctx context.Context, migration *Migration, db *sql.DB)
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 
+	info := newHookInfo(migration, store.DirectionDown, db, tx)
+	if err = callHook(ctx, m.hooks.BeforeEach, "before each", info); err != nil {
+		return rollbackTx(tx, err)
+	}
+
 	if err = migration.DownTx(ctx, tx); err != nil {
 		if txErr := tx.Rollback(); txErr != nil {
 			return fmt.Errorf("failed to down migration and rollback transaction: %w; %w", err, txErr)
@@ -240,6 +1092,10 @@ func (m Migrator) downTx(ctx context.Context, migration *Migration, db *sql.DB)
 		return fmt.Errorf("failed to insert migration from table: %w", err)
 	}
 
+	if err = callHook(ctx, m.hooks.AfterEach, "after each", info); err != nil {
+		return rollbackTx(tx, err)
+	}
+
 	if err = tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
@@ -248,30 +1104,145 @@ func (m Migrator) downTx(ctx context.Context, migration *Migration, db *sql.DB)
 }
 
 func (m Migrator) downNoTx(ctx context.Context, migration *Migration, db *sql.DB) error {
-	if err := migration.DownDB(ctx, db); err != nil {
+	info := newHookInfo(migration, store.DirectionDown, db, nil)
+	if err := callHook(ctx, m.hooks.BeforeEach, "before each", info); err != nil {
+		return err
+	}
+
+	from, err := m.startNoTx(ctx, migration, db, store.DirectionDown)
+	if err != nil {
+		return err
+	}
+
+	if err = migration.DownDBFrom(ctx, db, from, m.progressFn(migration, db, store.DirectionDown)); err != nil {
 		return fmt.Errorf("failed to down migration: %w", err)
 	}
 
-	if err := m.store.DeleteMigration(ctx, db, migration.ID()); err != nil {
+	if err = m.store.DeleteMigration(ctx, db, migration.ID()); err != nil {
 		return fmt.Errorf("failed to delete migration from table: %w", err)
 	}
 
+	if err = m.store.DeleteDirtyMarks(ctx, db, migration.ID()); err != nil {
+		return fmt.Errorf("failed to delete dirty mark: %w", err)
+	}
+
+	return callAfterRecorded(ctx, m.hooks.AfterEach, info)
+}
+
+// planMigration adds the migration to the plan of a dry run. A migration without transaction
+// interrupted in the same direction is planned with the statements left to execute.
+func (m Migrator) planMigration(
+	ctx context.Context, migration *Migration, db *sql.DB, direction string, noTx bool,
+) error {
+	var from int
+	if noTx {
+		var err error
+		if from, _, err = m.interruptedRun(ctx, migration, db, direction); err != nil {
+			return err
+		}
+	}
+
+	m.plan.add(migration, direction, noTx, from)
 	return nil
 }
 
+// startNoTx marks the migration as dirty before it's run without transaction. If the migration was interrupted
+// in the same direction, it returns the number of statements executed by then, so the run is resumed.
+func (m Migrator) startNoTx(ctx context.Context, migration *Migration, db *sql.DB, direction string) (int, error) {
+	executed, interrupted, err := m.interruptedRun(ctx, migration, db, direction)
+	if err != nil {
+		return 0, err
+	}
+	if interrupted {
+		return executed, nil
+	}
+
+	checksum := migration.ExecutedChecksum(direction, 0)
+	if err = m.store.InsertDirtyMark(ctx, db, migration.ID(), migration.Name(), direction, 0, checksum); err != nil {
+		return 0, fmt.Errorf("failed to mark migration as dirty: %w", err)
+	}
+
+	return 0, nil
+}
+
+// interruptedRun reports whether the run of the migration in the direction was interrupted,
+// and the number of statements executed by then.
+func (m Migrator) interruptedRun(
+	ctx context.Context, migration *Migration, db *sql.DB, direction string,
+) (executed int, interrupted bool, err error) {
+	mark, err := m.store.SelectDirtyMark(ctx, db)
+	if err != nil {
+		if errors.Is(err, store.ErrNoRows) {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("failed to select dirty mark: %w", err)
+	}
+	if mark.ID != migration.ID() || mark.Direction != direction {
+		return 0, false, nil
+	}
+
+	executed, err = resumeFrom(migration, mark)
+	if err != nil {
+		return 0, false, err
+	}
+	return executed, true, nil
+}
+
+// resumeFrom returns the number of statements executed by the interrupted run of the migration recorded
+// in the mark. It returns ErrStatementsChanged if the executed statements were edited since then,
+// skipping them would leave the database different from the migration.
+func resumeFrom(migration *Migration, mark store.DirtyMark) (int, error) {
+	checksum := migration.ExecutedChecksum(mark.Direction, mark.Statement)
+	if checksum == "" || checksum != mark.Checksum {
+		return 0, fmt.Errorf("%w: %s of migration with ID %d (%s) was interrupted after %d statement(s), "+
+			"fix the database and run repair", ErrStatementsChanged, mark.Direction, mark.ID, mark.Name, mark.Statement)
+	}
+
+	return mark.Statement, nil
+}
+
+// progressFn records the number of executed statements of a migration run without transaction.
+func (m Migrator) progressFn(migration *Migration, db *sql.DB, direction string) executor.ProgressFn {
+	return func(ctx context.Context, executed int) error {
+		checksum := migration.ExecutedChecksum(direction, executed)
+		return m.store.InsertDirtyMark(ctx, db, migration.ID(), migration.Name(), direction, executed, checksum)
+	}
+}
+
 func (m Migrator) getLastMigration(ctx context.Context, ms Migrations, db *sql.DB) (*Migration, error) {
 	lastID, err := m.store.SelectLastID(ctx, db)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get current database version: %w", err)
 	}
 
+	migration, err := findMigration(ms, lastID)
+	if err != nil {
+		return nil, fmt.Errorf("database version is %d, but migration with this ID not found", lastID)
+	}
+
+	return migration, nil
+}
+
+// findMigration returns a copy of the migration with the ID.
+func findMigration(ms Migrations, id int64) (*Migration, error) {
 	for _, migration := range ms {
-		if migration.ID() == lastID {
+		if migration.ID() == id {
 			return &migration, nil
 		}
 	}
 
-	return nil, fmt.Errorf("database version is %d, but migration with this ID not found", lastID)
+	return nil, fmt.Errorf("migration with ID %d is applied, but not found", id)
+}
+
+// filterUpTo returns migrations with ID less than or equal to version.
+func filterUpTo(migrations Migrations, version int64) Migrations {
+	filtered := make(Migrations, 0, len(migrations))
+	for _, migration := range migrations {
+		if migration.ID() <= version {
+			filtered = append(filtered, migration)
+		}
+	}
+	return filtered
 }
 
 func findMissingMigrations(migrations Migrations, results []MigrationResult) (missing Migrations, dirty bool) {
@@ -297,3 +1268,82 @@ func findMissingMigrations(migrations Migrations, results []MigrationResult) (mi
 
 	return missing, dirty
 }
+
+// PrepareMigrations prepares every migration in place: SQL files are read and parsed, Go migrations
+// are always prepared. It needs no database, so it validates migration files, e.g. in CI. It doesn't stop
+// at the first invalid migration and returns ErrInvalidMigrations with the errors of all of them,
+// e.g. *ParseError for SQL migrations.
+func PrepareMigrations(migrations Migrations) error {
+	var errs []error
+	for i := range migrations {
+		if err := migrations[i].ensureIsPrepared(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%w:\n%w", ErrInvalidMigrations, errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// findChecksum returns the checksum of the migration with the ID, or an empty string if it's not found.
+func findChecksum(migrations Migrations, id int64) (string, error) {
+	for _, migration := range migrations {
+		if migration.ID() != id {
+			continue
+		}
+		checksum, err := migration.Checksum()
+		if err != nil {
+			return "", fmt.Errorf("failed to calculate checksum of migration with ID %d: %w", id, err)
+		}
+		return checksum, nil
+	}
+	return "", nil
+}
+
+// markModified compares checksums recorded for applied migrations with the checksums of the migrations
+// and sets Modified of the changed ones. Rows without a checksum (Go migrations and migrations applied
+// before checksums were recorded) and migrations not found among the given ones are skipped.
+func markModified(migrations Migrations, results []MigrationResult) error {
+	byID := make(map[int64]Migration, len(migrations))
+	for _, migration := range migrations {
+		byID[migration.ID()] = migration
+	}
+
+	for i := range results {
+		migration, exists := byID[results[i].ID]
+		if !exists || results[i].Checksum == "" {
+			continue
+		}
+
+		checksum, err := migration.Checksum()
+		if err != nil {
+			return fmt.Errorf("failed to calculate checksum of migration with ID %d: %w", migration.ID(), err)
+		}
+		results[i].Modified = checksum != results[i].Checksum
+	}
+
+	return nil
+}
+
+// validateChecksums returns ErrModifiedMigrations listing applied migrations modified since they were applied.
+func validateChecksums(migrations Migrations, results []MigrationResult) error {
+	if err := markModified(migrations, results); err != nil {
+		return err
+	}
+
+	var modified []string
+	for _, r := range results {
+		if r.Modified {
+			modified = append(modified, fmt.Sprintf("%d (%s)", r.ID, r.Name))
+		}
+	}
+
+	if len(modified) > 0 {
+		return fmt.Errorf("%w: %s", ErrModifiedMigrations, strings.Join(modified, ", "))
+	}
+
+	return nil
+}
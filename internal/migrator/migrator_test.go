@@ -1,8 +1,11 @@
 package migrator
 
 import (
+	"fmt"
 	"testing"
+	"testing/fstest"
 
+	"github.com/evgodev/migratory/internal/migrator/parser"
 	"github.com/evgodev/migratory/internal/require"
 )
 
@@ -122,6 +125,24 @@ func TestFindMissingMigrations(t *testing.T) {
 	}
 }
 
+func TestFilterUpTo(t *testing.T) {
+	tests := map[string]struct {
+		version int64
+		want    Migrations
+	}{
+		"below all":  {version: 0, want: Migrations{}},
+		"first":      {version: 1, want: Migrations{Migration{id: 1}}},
+		"in between": {version: 3, want: Migrations{Migration{id: 1}, Migration{id: 2}, Migration{id: 3}}},
+		"above all":  {version: 100, want: allMigrations},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := filterUpTo(allMigrations, test.version)
+			require.Equal(t, got, test.want, "filterUpTo(...) migrations")
+		})
+	}
+}
+
 var allMigrations = Migrations{
 	Migration{id: 1},
 	Migration{id: 2},
@@ -147,3 +168,37 @@ var allResults = []MigrationResult{
 	{ID: 9},
 	{ID: 10},
 }
+
+func TestMarkModified(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"1_create.sql": {Data: []byte("-- +migrate up\nCREATE TABLE a (id int);\n-- +migrate down\nDROP TABLE a;\n")},
+		"2_edited.sql": {Data: []byte("-- +migrate up\nCREATE TABLE b (id int);\n-- +migrate down\nDROP TABLE b;\n")},
+	}
+	original := newSQLMigration(2, "edited", fstest.MapFS{
+		"2_edited.sql": {Data: []byte("-- +migrate up\nCREATE TABLE c (id int);\n-- +migrate down\nDROP TABLE c;\n")},
+	}, "2_edited.sql", parser.Options{})
+	originalChecksum, err := original.Checksum()
+	require.NoError(t, err, "original.Checksum() error")
+
+	unchanged := newSQLMigration(1, "create", fsys, "1_create.sql", parser.Options{})
+	unchangedChecksum, err := unchanged.Checksum()
+	require.NoError(t, err, "unchanged.Checksum() error")
+
+	migrations := Migrations{unchanged, newSQLMigration(2, "edited", fsys, "2_edited.sql", parser.Options{}), {id: 3}}
+	results := []MigrationResult{
+		{ID: 1, Checksum: unchangedChecksum},
+		{ID: 2, Checksum: originalChecksum},
+		{ID: 3}, // Go migration without checksum.
+		{ID: 4, Checksum: "unknown migration"},
+	}
+
+	err = markModified(migrations, results)
+	require.NoError(t, err, "markModified(...) error")
+	for i, want := range []bool{false, true, false, false} {
+		require.Bool(t, results[i].Modified, want, fmt.Sprintf("results[%d].Modified", i))
+	}
+
+	err = validateChecksums(migrations, results)
+	require.ErrorIs(t, err, ErrModifiedMigrations, "validateChecksums(...) error")
+}
@@ -12,6 +12,7 @@ const (
 	commandDown           commandBody   = "down"
 	commandStatementBegin commandBody   = "statement_begin"
 	commandStatementEnd   commandBody   = "statement_end"
+	commandNoTransaction  commandBody   = "no_transaction" // Only in files of one direction.
 	optionNoTransaction   commandOption = "no_transaction"
 )
 
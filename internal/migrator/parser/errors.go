@@ -0,0 +1,42 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ParseError describes an error in a migration file with its position.
+// Err is one of the package errors, e.g. ErrNoSemicolon, so it can be checked with errors.Is.
+type ParseError struct {
+	File      string // Path of the migration file, empty if unknown.
+	Line      int    // 1-based line number, 0 if the error concerns the whole file.
+	Column    int    // 1-based column number.
+	Command   string // The offending migration command without the "-- +migrate" prefix, if any.
+	Direction string // Section of the error, "up" or "down", empty before the first of them.
+	Err       error
+}
+
+// Error formats the error compiler-style, e.g. `01_users.sql:42:1: unknown migration command "statment_end"`.
+func (e *ParseError) Error() string {
+	msg := e.Err.Error()
+	if errors.Is(e.Err, ErrUnknownCommand) {
+		msg = fmt.Sprintf("%s %q", msg, e.Command)
+	}
+
+	position := e.File
+	if e.Line > 0 {
+		position = fmt.Sprintf("%d:%d", e.Line, e.Column)
+		if e.File != "" {
+			position = e.File + ":" + position
+		}
+	}
+
+	if position == "" {
+		return msg
+	}
+	return position + ": " + msg
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,182 @@
+package parser
+
+import "strings"
+
+// lexState is the context the lexer is in at the end of a scanned line.
+// Quoted strings, dollar-quoted bodies and block comments may span several lines.
+type lexState int
+
+const (
+	lexCode          lexState = iota
+	lexString                 // '...', a quote is escaped by doubling it (or by a backslash, see backslashEscapes).
+	lexEscapeString           // E'...', also a backslash escapes the next character.
+	lexQuotedIdent            // "...", a quote is escaped by doubling it (or by a backslash, see backslashEscapes).
+	lexBacktickIdent          // `...` (MySQL).
+	lexDollarQuote            // $tag$...$tag$ (Postgres function bodies).
+	lexBlockComment           // /* ... */, may be nested.
+)
+
+// segment is a part of a line ending with a semicolon that terminates a statement,
+// or the rest of the line after the last one.
+type segment struct {
+	text    string
+	start   int  // 0-based column of the segment in the line.
+	ended   bool // The segment ends with a statement terminating semicolon.
+	hasCode bool // The segment contains SQL other than whitespace and comments.
+}
+
+// lexer splits SQL into statements by semicolons outside of string literals, quoted identifiers,
+// dollar-quoted bodies and comments. By default strings in single quotes follow the SQL standard
+// (Postgres, SQLite), a backslash escapes characters only in E'...' strings.
+type lexer struct {
+	// backslashEscapes makes a backslash escape the next character in '...' and "..." too,
+	// as in MySQL and ClickHouse, e.g. 'it\'s'.
+	backslashEscapes bool
+
+	state lexState
+	tag   string // Tag of the current dollar quote, including the dollar signs.
+	depth int    // Nesting depth of the current block comment.
+
+	line                 int // Number of the line being split, set by the parser.
+	openLine, openColumn int // Position where the current quoted text or block comment was opened.
+}
+
+// inCode reports whether the lexer is outside of any quoted text or block comment.
+func (l *lexer) inCode() bool {
+	return l.state == lexCode
+}
+
+// split lexes the line, continuing the state of the previous lines, and splits it into segments
+// by statement terminating semicolons. The last segment is the rest of the line, it may be empty.
+func (l *lexer) split(line string) []segment {
+	var segments []segment
+	var hasCode bool
+	start := 0
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch l.state {
+		case lexCode:
+			// Remembered for quoted text or a block comment opened at this character.
+			l.openLine, l.openColumn = l.line, i+1
+			switch {
+			case c == ';':
+				segments = append(segments, segment{text: line[start : i+1], start: start, ended: true, hasCode: true})
+				start = i + 1
+				hasCode = false
+				continue
+			case c == '-' && strings.HasPrefix(line[i:], "--"):
+				// The rest of the line is a comment.
+				i = len(line)
+				continue
+			case c == '/' && strings.HasPrefix(line[i:], "/*"):
+				l.state, l.depth = lexBlockComment, 1
+				i++
+				continue
+			case c == '\'':
+				l.state = lexString
+				if i > 0 && (line[i-1] == 'E' || line[i-1] == 'e') && (i == 1 || !isIdentChar(line[i-2])) {
+					l.state = lexEscapeString
+				}
+			case c == '"':
+				l.state = lexQuotedIdent
+			case c == '`':
+				l.state = lexBacktickIdent
+			case c == '$':
+				if tag := dollarTag(line, i); tag != "" {
+					l.state, l.tag = lexDollarQuote, tag
+					i += len(tag) - 1
+				}
+			}
+			if c != ' ' && c != '\t' && c != '\r' {
+				hasCode = true
+			}
+
+		case lexString, lexQuotedIdent, lexBacktickIdent:
+			hasCode = true
+			if c == '\\' && l.backslashEscapes && l.state != lexBacktickIdent {
+				i++
+				continue
+			}
+			if quote := closingQuote(l.state); c == quote {
+				if i+1 < len(line) && line[i+1] == quote {
+					i++ // Escaped quote.
+					continue
+				}
+				l.state = lexCode
+			}
+
+		case lexEscapeString:
+			hasCode = true
+			switch {
+			case c == '\\':
+				i++
+			case c == '\'' && i+1 < len(line) && line[i+1] == '\'':
+				i++
+			case c == '\'':
+				l.state = lexCode
+			}
+
+		case lexDollarQuote:
+			hasCode = true
+			if c == '$' && strings.HasPrefix(line[i:], l.tag) {
+				i += len(l.tag) - 1
+				l.state, l.tag = lexCode, ""
+			}
+
+		case lexBlockComment:
+			switch {
+			case c == '/' && strings.HasPrefix(line[i:], "/*"):
+				l.depth++
+				i++
+			case c == '*' && strings.HasPrefix(line[i:], "*/"):
+				l.depth--
+				i++
+				if l.depth == 0 {
+					l.state = lexCode
+				}
+			}
+		}
+	}
+
+	return append(segments, segment{text: line[start:], start: start, hasCode: hasCode})
+}
+
+func closingQuote(state lexState) byte {
+	switch state {
+	case lexQuotedIdent:
+		return '"'
+	case lexBacktickIdent:
+		return '`'
+	default:
+		return '\''
+	}
+}
+
+// dollarTag returns the opening tag of a dollar quote ($$ or $tag$) starting at the index i of the line,
+// or an empty string if there is none, e.g. for positional parameters like $1 or identifiers like a$b.
+func dollarTag(line string, i int) string {
+	if i > 0 && isIdentChar(line[i-1]) {
+		return ""
+	}
+
+	for j := i + 1; j < len(line); j++ {
+		c := line[j]
+		switch {
+		case c == '$':
+			return line[i : j+1]
+		case c >= '0' && c <= '9':
+			if j == i+1 {
+				return ""
+			}
+		case !isIdentChar(c):
+			return ""
+		}
+	}
+
+	return ""
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
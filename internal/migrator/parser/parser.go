@@ -1,4 +1,5 @@
 // Package parser provides functionality to parse SQL migrations, divide it to up and down SQL statements.
+// Statements are split by a lexer aware of string literals, quoted identifiers, dollar-quoted bodies and comments.
 // See testdata for examples.
 package parser
 
@@ -8,17 +9,27 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 )
 
 var (
 	ErrNoSemicolon         = errors.New("statement must be ended by a semicolon")
 	ErrIncompleteCommand   = errors.New("incomplete migration command")
-	ErrUnknownCommand      = errors.New("unknown migration command after prefix")
+	ErrUnknownCommand      = errors.New("unknown migration command")
 	ErrStatementNotEnded   = errors.New("statement was started but not ended")
 	ErrStatementNotStarted = errors.New("statement was ended but not started")
 	ErrNoUpDownCommands    = errors.New("no Up and Down commands found during parsing")
+	ErrUnterminatedQuote   = errors.New("quoted text, dollar-quoted body or block comment is not terminated")
+	ErrDirectionCommand    = errors.New("up and down commands are not allowed in a file of one direction")
 )
 
+// Options configures the SQL syntax of the dialect the migration is written for.
+type Options struct {
+	// BackslashEscapes makes a backslash escape the next character in '...' and "..." strings,
+	// as in MySQL and ClickHouse. Otherwise a backslash is an ordinary character there (Postgres, SQLite).
+	BackslashEscapes bool
+}
+
 // ParsedMigration describes up and down SQL statements.
 type ParsedMigration struct {
 	UpStatements   []string
@@ -30,63 +41,98 @@ type ParsedMigration struct {
 
 // ParseMigration parses SQL migration scripts into up and down statements, handling specific commands and identifiers.
 // It returns a ParsedMigration with the parsed statements and transactions configuration or an error on failure.
-func ParseMigration(r io.Reader) (*ParsedMigration, error) {
-	p := newParser(r)
+// Errors in the migration are returned as *ParseError with the position of the error, its File is empty.
+func ParseMigration(r io.Reader, opts Options) (*ParsedMigration, error) {
+	p := newParser(r, opts)
 	if err := p.parseLines(); err != nil {
-		return nil, fmt.Errorf("failed to parse lines: %w", err)
+		return nil, err
 	}
 
-	result, err := p.getResult()
-	if err != nil {
-		return nil, fmt.Errorf("failed to finalize parsing: %w", err)
+	return p.getResult()
+}
+
+// ParseDirection parses a file holding the statements of one direction only, like the up and down files
+// of golang-migrate (001_users.up.sql and 001_users.down.sql), which have no up and down commands.
+// The statements are returned in UpStatements or DownStatements by the direction, statement_begin and
+// statement_end commands may be used, and the no_transaction command runs the file without transaction.
+// Errors are returned as *ParseError, like by ParseMigration.
+func ParseDirection(r io.Reader, down bool, opts Options) (*ParsedMigration, error) {
+	p := newParser(r, opts)
+	p.singleDirection = true
+	p.state.setDirectionUp()
+	if down {
+		p.state.setDirectionDown()
+	}
+
+	if err := p.parseLines(); err != nil {
+		return nil, err
 	}
 
-	return result, nil
+	return p.getResult()
 }
 
 type parser struct {
 	scanner *bufio.Scanner
 	buffer  *bytes.Buffer
 	state   *parsingState
+	lexer   *lexer
 	result  *ParsedMigration
+
+	// hasCode is set when the buffer contains SQL other than whitespace and comments.
+	hasCode bool
+	// singleDirection is set when the direction is defined by the file name rather than commands.
+	singleDirection bool
+
+	line int // Number of the current line.
+	// Position of the first line of the buffered statement, or of the statement_begin command.
+	statementLine, statementColumn int
 }
 
-func newParser(r io.Reader) *parser {
+func newParser(r io.Reader, opts Options) *parser {
 	return &parser{
 		scanner: bufio.NewScanner(r),
 		buffer:  &bytes.Buffer{},
 		state:   newParsingState(),
+		lexer:   &lexer{backslashEscapes: opts.BackslashEscapes},
 		result:  &ParsedMigration{},
 	}
 }
 
+// parseLines splits the migration into statements. Statements between statement_begin and statement_end
+// commands are taken as is, other statements are split by the lexer, so semicolons in string literals,
+// quoted identifiers, dollar-quoted bodies and comments don't end a statement.
 func (p *parser) parseLines() error {
 	for p.scanner.Scan() {
 		line := p.scanner.Text()
+		p.line++
+		p.lexer.line = p.line
 
-		if isEmpty(line) || isSQLComment(line) {
-			continue
-		}
-
-		if isCommand(line) {
-			if err := p.handleCommand(line); err != nil {
-				return err
+		// Lines inside quoted text or a block comment are kept as is, even if they look like commands.
+		if p.lexer.inCode() {
+			if isEmpty(line) || isSQLComment(line) {
+				continue
 			}
-		} else {
-			if err := p.writeToBuffer(line); err != nil {
-				return err
+
+			if isCommand(line) {
+				if err := p.handleCommand(line); err != nil {
+					return p.commandError(err, line)
+				}
+				if p.state.isStatementEnded() {
+					p.flushStatement()
+				}
+				continue
 			}
 		}
 
-		if p.state.isStatementEnded() || (p.state.isStatementNone() && endsWithSemicolon(line)) {
-			if p.state.direction == directionUp {
-				p.result.UpStatements = append(p.result.UpStatements, p.buffer.String())
-			} else {
-				p.result.DownStatements = append(p.result.DownStatements, p.buffer.String())
+		if !p.state.isStatementNone() {
+			if err := p.writeToBuffer(line, 0); err != nil {
+				return err
 			}
+			continue
+		}
 
-			p.state.setStatementNone()
-			p.buffer.Reset()
+		if err := p.splitLine(line); err != nil {
+			return err
 		}
 	}
 
@@ -97,16 +143,80 @@ func (p *parser) parseLines() error {
 	return nil
 }
 
+// splitLine adds the line to the current statement and flushes statements ended in it. A statement ends
+// at the end of the line if only whitespace and comments follow its semicolon, otherwise the rest
+// of the line starts the next statement.
+func (p *parser) splitLine(line string) error {
+	segments := p.lexer.split(line)
+	for i := 0; i < len(segments); i++ {
+		s := segments[i]
+		column := s.start
+		if i > 0 && p.buffer.Len() == 0 {
+			// The statement starts after the semicolon of the previous one.
+			trimmed := strings.TrimLeft(s.text, " \t")
+			column += len(s.text) - len(trimmed)
+			s.text = trimmed
+		}
+		p.hasCode = p.hasCode || s.hasCode
+
+		if !s.ended {
+			if err := p.writeToBuffer(s.text, column); err != nil {
+				return err
+			}
+			continue
+		}
+
+		text := s.text
+		if next := segments[i+1]; !next.ended && !next.hasCode {
+			// A trailing comment belongs to the statement.
+			text += next.text
+			i++
+		}
+		if err := p.writeToBuffer(text, column); err != nil {
+			return err
+		}
+		p.flushStatement()
+	}
+
+	return nil
+}
+
+// flushStatement adds the buffered statement to the current direction.
+func (p *parser) flushStatement() {
+	if p.state.direction == directionUp {
+		p.result.UpStatements = append(p.result.UpStatements, p.buffer.String())
+	} else {
+		p.result.DownStatements = append(p.result.DownStatements, p.buffer.String())
+	}
+
+	p.state.setStatementNone()
+	p.buffer.Reset()
+	p.hasCode = false
+}
+
+// hasPendingStatement reports whether the buffer holds a statement without a terminating semicolon.
+// A buffer with comments only is dropped.
+func (p *parser) hasPendingStatement() bool {
+	if !p.hasCode {
+		p.buffer.Reset()
+	}
+	return p.buffer.Len() > 0
+}
+
 func (p *parser) handleCommand(line string) error {
 	cmd, err := newCommand(line)
 	if err != nil {
 		return err
 	}
 
+	if p.singleDirection && (cmd.body == commandUp || cmd.body == commandDown) {
+		return ErrDirectionCommand
+	}
+
 	switch cmd.body {
 	case commandUp:
-		if p.buffer.Len() > 0 {
-			return ErrNoSemicolon
+		if p.hasPendingStatement() {
+			return p.statementError(ErrNoSemicolon)
 		}
 		p.state.setDirectionUp()
 		if cmd.hasOption(optionNoTransaction) {
@@ -114,8 +224,8 @@ func (p *parser) handleCommand(line string) error {
 		}
 
 	case commandDown:
-		if p.buffer.Len() > 0 {
-			return ErrNoSemicolon
+		if p.hasPendingStatement() {
+			return p.statementError(ErrNoSemicolon)
 		}
 		p.state.setDirectionDown()
 		if cmd.hasOption(optionNoTransaction) {
@@ -124,10 +234,23 @@ func (p *parser) handleCommand(line string) error {
 
 	case commandStatementBegin:
 		p.state.setStatementStarted()
+		p.statementLine, p.statementColumn = p.line, 1
+		// The statement is closed by the statement_end command, whatever it contains.
+		p.hasCode = true
 
 	case commandStatementEnd:
 		return p.state.setStatementEnded()
 
+	case commandNoTransaction:
+		if !p.singleDirection {
+			return ErrUnknownCommand
+		}
+		if p.state.direction == directionUp {
+			p.result.DisableTransactionUp = true
+		} else {
+			p.result.DisableTransactionDown = true
+		}
+
 	default:
 		return ErrUnknownCommand
 	}
@@ -137,18 +260,55 @@ func (p *parser) handleCommand(line string) error {
 
 func (p *parser) getResult() (*ParsedMigration, error) {
 	if p.state.statement == statementStarted {
-		return nil, ErrStatementNotEnded
+		err := p.statementError(ErrStatementNotEnded)
+		err.Command = commandStatementBegin
+		return nil, err
+	}
+	if !p.lexer.inCode() {
+		return nil, p.errorAt(ErrUnterminatedQuote, p.lexer.openLine, p.lexer.openColumn)
 	}
 	if p.state.direction == directionNone {
-		return nil, ErrNoUpDownCommands
+		return nil, p.errorAt(ErrNoUpDownCommands, 0, 0)
 	}
-	if p.buffer.Len() > 0 {
-		return nil, ErrNoSemicolon
+	if p.hasPendingStatement() {
+		return nil, p.statementError(ErrNoSemicolon)
 	}
 	return p.result, nil
 }
 
-func (p *parser) writeToBuffer(line string) error {
+// errorAt returns a ParseError at the position in the current direction section.
+func (p *parser) errorAt(err error, line, column int) *ParseError {
+	return &ParseError{
+		Line:      line,
+		Column:    column,
+		Direction: p.state.direction.String(),
+		Err:       err,
+	}
+}
+
+// statementError returns a ParseError at the start of the buffered statement.
+func (p *parser) statementError(err error) *ParseError {
+	return p.errorAt(err, p.statementLine, p.statementColumn)
+}
+
+// commandError returns a ParseError at the command line, unless err is already positioned.
+func (p *parser) commandError(err error, line string) error {
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		return err
+	}
+
+	parseErr = p.errorAt(err, p.line, 1)
+	parseErr.Command = strings.TrimSpace(strings.TrimPrefix(line, commandPrefix))
+	return parseErr
+}
+
+// writeToBuffer adds the text of the current line starting at the 0-based column to the statement.
+func (p *parser) writeToBuffer(line string, column int) error {
+	if p.buffer.Len() == 0 && p.state.isStatementNone() {
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		p.statementLine, p.statementColumn = p.line, column+indent+1
+	}
 	if _, err := p.buffer.WriteString(line + "\n"); err != nil {
 		return fmt.Errorf("failed to write string to buffer: %w", err)
 	}
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -15,28 +16,105 @@ const (
 	invalidDataPath = "testdata/invalid/"
 )
 
-func TestEndsWithSemicolon(t *testing.T) {
+func TestLexerSplit(t *testing.T) {
 	t.Parallel()
 	tests := map[string]struct {
-		line string
-		want bool
+		line      string
+		wantEnded []string // Texts of segments ended by a semicolon.
+		wantRest  string
 	}{
-		"only semicolon":            {line: ";", want: true},
-		"semicolon":                 {line: "END;", want: true},
-		"with comment":              {line: "END; -- comment", want: true},
-		"with spaces":               {line: "END   ; -- comment", want: true},
-		"empty":                     {line: "", want: false},
-		"no semicolon":              {line: "END", want: false},
-		"no semicolon with comment": {line: "END -- comment", want: false},
-		"semicolon in comment":      {line: "END -- comment ;", want: false},
+		"only semicolon":            {line: ";", wantEnded: []string{";"}},
+		"semicolon":                 {line: "END;", wantEnded: []string{"END;"}},
+		"with comment":              {line: "END; -- comment", wantEnded: []string{"END;"}, wantRest: " -- comment"},
+		"empty":                     {line: ""},
+		"no semicolon":              {line: "END", wantRest: "END"},
+		"semicolon in comment":      {line: "END -- comment ;", wantRest: "END -- comment ;"},
+		"two statements":            {line: "SELECT 1; SELECT 2;", wantEnded: []string{"SELECT 1;", " SELECT 2;"}},
+		"semicolon in string":       {line: "SELECT 'a;b';", wantEnded: []string{"SELECT 'a;b';"}},
+		"dashes in string":          {line: "SELECT 'a--b';", wantEnded: []string{"SELECT 'a--b';"}},
+		"escaped quote":             {line: "SELECT 'it''s;';", wantEnded: []string{"SELECT 'it''s;';"}},
+		"E-string":                  {line: `SELECT E'it\'s;';`, wantEnded: []string{`SELECT E'it\'s;';`}},
+		"backslash in string":       {line: `SELECT 'a\'; SELECT 1;`, wantEnded: []string{`SELECT 'a\';`, " SELECT 1;"}},
+		"quoted identifier":         {line: `SELECT 1 AS "a;b";`, wantEnded: []string{`SELECT 1 AS "a;b";`}},
+		"backtick identifier":       {line: "SELECT 1 AS `a;b`;", wantEnded: []string{"SELECT 1 AS `a;b`;"}},
+		"dollar quote":              {line: "DO $$ BEGIN NULL; END $$;", wantEnded: []string{"DO $$ BEGIN NULL; END $$;"}},
+		"tagged dollar quote":       {line: "DO $f$ SELECT '$$;'; $f$;", wantEnded: []string{"DO $f$ SELECT '$$;'; $f$;"}},
+		"positional parameter":      {line: "SELECT $1; SELECT $2;", wantEnded: []string{"SELECT $1;", " SELECT $2;"}},
+		"block comment":             {line: "SELECT /* ; */ 1;", wantEnded: []string{"SELECT /* ; */ 1;"}},
+		"nested block comment":      {line: "SELECT /* /* ; */ ; */ 1;", wantEnded: []string{"SELECT /* /* ; */ ; */ 1;"}},
+		"unterminated dollar quote": {line: "DO $$ BEGIN NULL;", wantRest: "DO $$ BEGIN NULL;"},
 	}
 
 	for name, test := range tests {
 		test := test
 		t.Run(name, func(t *testing.T) {
 			t.Parallel()
-			got := endsWithSemicolon(test.line)
-			require.Bool(t, got, test.want, "endsWithSemicolon(...) result")
+			segments := (&lexer{}).split(test.line)
+
+			var ended []string
+			for _, s := range segments[:len(segments)-1] {
+				require.Bool(t, s.ended, true, "split(...) segments before the last must be ended")
+				ended = append(ended, s.text)
+			}
+			require.Equal(t, ended, test.wantEnded, "split(...) ended segments")
+			require.String(t, segments[len(segments)-1].text, test.wantRest, "split(...) rest of the line")
+		})
+	}
+}
+
+func TestLexerSplitBackslashEscapes(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		line      string
+		wantEnded []string
+	}{
+		"escaped quote":            {line: `SELECT 'it\'s; fine';`, wantEnded: []string{`SELECT 'it\'s; fine';`}},
+		"escaped backslash":        {line: `SELECT 'a\\'; SELECT 1;`, wantEnded: []string{`SELECT 'a\\';`, " SELECT 1;"}},
+		"escaped double quote":     {line: `SELECT "a\"; b";`, wantEnded: []string{`SELECT "a\"; b";`}},
+		"doubled quote":            {line: "SELECT 'it''s;';", wantEnded: []string{"SELECT 'it''s;';"}},
+		"backslash in backticks":   {line: "SELECT `a\\`; SELECT 2;", wantEnded: []string{"SELECT `a\\`;", " SELECT 2;"}},
+		"E-string escaped quote":   {line: `SELECT E'it\'s;';`, wantEnded: []string{`SELECT E'it\'s;';`}},
+		"escaped quote at the end": {line: `SELECT 'a\';'; SELECT 1;`, wantEnded: []string{`SELECT 'a\';';`, " SELECT 1;"}},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			segments := (&lexer{backslashEscapes: true}).split(test.line)
+
+			var ended []string
+			for _, s := range segments[:len(segments)-1] {
+				ended = append(ended, s.text)
+			}
+			require.Equal(t, ended, test.wantEnded, "split(...) ended segments")
+			require.String(t, segments[len(segments)-1].text, "", "split(...) rest of the line")
+		})
+	}
+}
+
+func TestIsDDL(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		statement string
+		want      bool
+	}{
+		"create table":   {statement: "CREATE TABLE users (id INT);", want: true},
+		"lowercase":      {statement: "alter table users add column name text;", want: true},
+		"leading spaces": {statement: "\n  DROP INDEX idx;\n", want: true},
+		"rename":         {statement: "RENAME TABLE a TO b;", want: true},
+		"truncate":       {statement: "TRUNCATE users;", want: true},
+		"insert":         {statement: "INSERT INTO users VALUES (1);", want: false},
+		"select":         {statement: "SELECT 'CREATE';", want: false},
+		"empty":          {statement: "", want: false},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got := IsDDL(test.statement)
+			require.Bool(t, got, test.want, "IsDDL(...) result")
 		})
 	}
 }
@@ -47,7 +125,7 @@ func TestParseValid(t *testing.T) {
 	for _, path := range fileNames {
 		file := openFile(t, path)
 
-		_, err := ParseMigration(file)
+		_, err := ParseMigration(file, Options{})
 		require.NoError(t, err, fmt.Sprintf("ParseMigration(...) must execute without error, file %s", path))
 
 		closeFile(t, file)
@@ -60,7 +138,7 @@ func TestParseInvalid(t *testing.T) {
 	for _, path := range fileNames {
 		file := openFile(t, path)
 
-		_, err := ParseMigration(file)
+		_, err := ParseMigration(file, Options{})
 		require.Error(t, err, fmt.Sprintf("ParseMigration(...) must execute with error, file %s", path))
 
 		closeFile(t, file)
@@ -131,6 +209,24 @@ DROP TABLE employees;
 			upCount:   6,
 			downCount: 4,
 		},
+		"without statement commands": {
+			sql: `
+-- +migrate up
+CREATE FUNCTION touch() RETURNS trigger AS $$
+BEGIN
+    NEW.note := 'updated; -- not a comment';
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+/* block comment; /* nested; */ still a comment; */
+INSERT INTO notes (body) VALUES ('a--b'), (E'it\'s;'); INSERT INTO "odd;name" VALUES (1);
+-- +migrate down
+DROP FUNCTION touch(); -- trailing comment
+`,
+			upCount:   3,
+			downCount: 1,
+		},
 		"noUp": {
 			sql: `
 -- finally added down statement
@@ -160,7 +256,7 @@ CREATE TABLE users (
 		test := test
 		t.Run(name, func(t *testing.T) {
 			t.Parallel()
-			migration, err := ParseMigration(strings.NewReader(test.sql))
+			migration, err := ParseMigration(strings.NewReader(test.sql), Options{})
 			require.NoError(t, err, "ParseMigration(...)")
 			require.Int(t, len(migration.UpStatements), test.upCount, "UpStatements count")
 			require.Int(t, len(migration.DownStatements), test.downCount, "DownStatements count")
@@ -168,6 +264,146 @@ CREATE TABLE users (
 	}
 }
 
+func TestParseDirection(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		sql       string
+		down      bool
+		upCount   int
+		downCount int
+		wantNoTx  bool
+		wantErr   error
+	}{
+		"up":    {sql: "CREATE TABLE a (id INT);\nINSERT INTO a VALUES (1);\n", upCount: 2},
+		"down":  {sql: "-- comment\nDROP TABLE a;\n", down: true, downCount: 1},
+		"empty": {sql: "", upCount: 0},
+		"statement block": {
+			sql:     "-- +migrate statement_begin\nSELECT 1; SELECT 2;\n-- +migrate statement_end\n",
+			upCount: 1,
+		},
+		"up no transaction": {
+			sql:      "-- +migrate no_transaction\nCREATE INDEX i ON a (id);\n",
+			upCount:  1,
+			wantNoTx: true,
+		},
+		"down no transaction": {
+			sql:       "-- +migrate no_transaction\nDROP INDEX i;\n",
+			down:      true,
+			downCount: 1,
+			wantNoTx:  true,
+		},
+		"direction command": {sql: "-- +migrate up\nSELECT 1;\n", wantErr: ErrDirectionCommand},
+		"direction command with option": {
+			sql:     "-- +migrate down no_transaction\nSELECT 1;\n",
+			down:    true,
+			wantErr: ErrDirectionCommand,
+		},
+		"no semicolon": {sql: "SELECT 1\n", down: true, wantErr: ErrNoSemicolon},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			migration, err := ParseDirection(strings.NewReader(test.sql), test.down, Options{})
+			require.ErrorIs(t, err, test.wantErr, "ParseDirection(...) error")
+			if test.wantErr != nil {
+				return
+			}
+			require.Int(t, len(migration.UpStatements), test.upCount, "UpStatements count")
+			require.Int(t, len(migration.DownStatements), test.downCount, "DownStatements count")
+			noTx := migration.DisableTransactionUp
+			if test.down {
+				noTx = migration.DisableTransactionDown
+			}
+			require.Bool(t, noTx, test.wantNoTx, "DisableTransaction of the direction")
+		})
+	}
+}
+
+func TestParseErrorPosition(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		sql  string
+		want ParseError
+	}{
+		"unknown command": {
+			sql:  "-- +migrate up\nCREATE TABLE a (id INT);\n-- +migrate statment_end\n",
+			want: ParseError{Line: 3, Column: 1, Command: "statment_end", Direction: "up", Err: ErrUnknownCommand},
+		},
+		"no transaction command with up and down commands": {
+			sql:  "-- +migrate up\n-- +migrate no_transaction\nSELECT 1;\n",
+			want: ParseError{Line: 2, Column: 1, Command: "no_transaction", Direction: "up", Err: ErrUnknownCommand},
+		},
+		"statement not started": {
+			sql:  "-- +migrate up\n-- +migrate down\n-- +migrate statement_end\n",
+			want: ParseError{Line: 3, Column: 1, Command: "statement_end", Direction: "down", Err: ErrStatementNotStarted},
+		},
+		"no semicolon before down": {
+			sql:  "-- +migrate up\nSELECT 1; SELECT 2\n-- +migrate down\n",
+			want: ParseError{Line: 2, Column: 11, Direction: "up", Err: ErrNoSemicolon},
+		},
+		"no semicolon at the end": {
+			sql:  "-- +migrate up\n-- +migrate down\n\n  DROP TABLE a\n",
+			want: ParseError{Line: 4, Column: 3, Direction: "down", Err: ErrNoSemicolon},
+		},
+		"statement not ended": {
+			sql:  "-- +migrate up\n-- +migrate statement_begin\nSELECT 1;\n",
+			want: ParseError{Line: 2, Column: 1, Command: "statement_begin", Direction: "up", Err: ErrStatementNotEnded},
+		},
+		"unterminated quote": {
+			sql:  "-- +migrate up\nSELECT 1;\nSELECT 'a;\nb;\n",
+			want: ParseError{Line: 3, Column: 8, Direction: "up", Err: ErrUnterminatedQuote},
+		},
+		"no up and down commands": {
+			sql:  "SELECT 1;\n",
+			want: ParseError{Err: ErrNoUpDownCommands},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			_, err := ParseMigration(strings.NewReader(test.sql), Options{})
+			require.ErrorIs(t, err, test.want.Err, "ParseMigration(...) error")
+
+			var parseErr *ParseError
+			require.Bool(t, errors.As(err, &parseErr), true, "ParseMigration(...) error must be a ParseError")
+			require.Equal(t, *parseErr, test.want, "ParseMigration(...) error position")
+		})
+	}
+}
+
+func TestParseErrorString(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		err  ParseError
+		want string
+	}{
+		"unknown command": {
+			err:  ParseError{File: "42_users.sql", Line: 42, Column: 1, Command: "statment_end", Err: ErrUnknownCommand},
+			want: `42_users.sql:42:1: unknown migration command "statment_end"`,
+		},
+		"without file": {
+			err:  ParseError{Line: 3, Column: 5, Err: ErrNoSemicolon},
+			want: "3:5: statement must be ended by a semicolon",
+		},
+		"whole file": {
+			err:  ParseError{File: "42_users.sql", Err: ErrNoUpDownCommands},
+			want: "42_users.sql: " + ErrNoUpDownCommands.Error(),
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			require.String(t, test.err.Error(), test.want, "Error() result")
+		})
+	}
+}
+
 func getDirectoryFilenames(t *testing.T, path string) []string {
 	t.Helper()
 	fileNames, err := filepath.Glob(path + "*.sql")
@@ -23,6 +23,17 @@ const (
 	statementEnded
 )
 
+func (d migrationDirection) String() string {
+	switch d {
+	case directionUp:
+		return "up"
+	case directionDown:
+		return "down"
+	default:
+		return ""
+	}
+}
+
 func newParsingState() *parsingState {
 	return &parsingState{
 		direction: directionNone,
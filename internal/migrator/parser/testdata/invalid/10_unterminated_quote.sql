@@ -0,0 +1,8 @@
+-- +migrate up
+CREATE FUNCTION f() RETURNS void AS $$
+BEGIN
+    NULL;
+END;
+
+-- +migrate down
+DROP FUNCTION f();
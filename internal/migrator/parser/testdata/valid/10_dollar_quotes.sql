@@ -0,0 +1,16 @@
+-- +migrate up
+CREATE OR REPLACE FUNCTION set_updated_at() RETURNS trigger AS $body$
+BEGIN
+    NEW.updated_at := now();
+    RETURN NEW;
+END;
+$body$ LANGUAGE plpgsql;
+
+/*
+ * Semicolons in block comments; string literals and quoted identifiers don't end statements.
+ */
+INSERT INTO settings ("key;name", value) VALUES ('separator', ';');
+
+-- +migrate down
+DROP FUNCTION set_updated_at();
+DELETE FROM settings WHERE "key;name" = 'separator';
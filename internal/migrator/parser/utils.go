@@ -9,15 +9,6 @@ const (
 	commandPrefix    = "-- +migrate"
 )
 
-// endsWithSemicolon checks if the given line ends with a semicolon, ignoring SQL comments and trailing whitespace.
-func endsWithSemicolon(line string) bool {
-	if idx := strings.Index(line, sqlCommentPrefix); idx >= 0 {
-		line = line[:idx]
-	}
-	line = strings.TrimSpace(line)
-	return len(line) > 0 && line[len(line)-1] == ';'
-}
-
 func isCommand(line string) bool {
 	return strings.HasPrefix(line, commandPrefix)
 }
@@ -29,3 +20,22 @@ func isSQLComment(line string) bool {
 func isEmpty(line string) bool {
 	return strings.TrimSpace(line) == ""
 }
+
+// ddlKeywords are the first keywords of statements changing the database schema.
+var ddlKeywords = map[string]struct{}{
+	"CREATE":   {},
+	"ALTER":    {},
+	"DROP":     {},
+	"RENAME":   {},
+	"TRUNCATE": {},
+}
+
+// IsDDL checks if the statement changes the database schema (CREATE, ALTER, DROP, RENAME or TRUNCATE).
+func IsDDL(statement string) bool {
+	fields := strings.Fields(statement)
+	if len(fields) == 0 {
+		return false
+	}
+	_, ok := ddlKeywords[strings.ToUpper(fields[0])]
+	return ok
+}
@@ -1,37 +1,114 @@
 package migrator
 
 import (
+	"errors"
 	"fmt"
-	"os"
+	"io"
+	"io/fs"
 
 	"github.com/evgodev/migratory/internal/migrator/executor"
 	"github.com/evgodev/migratory/internal/migrator/parser"
+	"github.com/evgodev/migratory/internal/migrator/store"
 )
 
 type sqlPreparer struct {
+	fsys     fs.FS
 	filePath string
+	opts     parser.Options
+
+	// split is set for migrations with up and down statements in separate files without commands,
+	// filePath is the up file then, and downFilePath is the down file, empty if there is none.
+	split        bool
+	downFilePath string
 }
 
-func newSQLPreparer(filePath string) *sqlPreparer {
+func newSQLPreparer(fsys fs.FS, filePath string, opts parser.Options) *sqlPreparer {
 	return &sqlPreparer{
+		fsys:     fsys,
 		filePath: filePath,
+		opts:     opts,
 	}
 }
 
 func (s sqlPreparer) Prepare() (*executors, error) {
-	file, err := os.Open(s.filePath)
+	parsed, err := s.Parse()
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file at path %s: %w", s.filePath, err)
+		return nil, err
+	}
+
+	return newSQLExecutors(parsed), nil
+}
+
+func newSplitSQLPreparer(fsys fs.FS, upFilePath, downFilePath string, opts parser.Options) *sqlPreparer {
+	return &sqlPreparer{
+		fsys:         fsys,
+		filePath:     upFilePath,
+		opts:         opts,
+		split:        true,
+		downFilePath: downFilePath,
+	}
+}
+
+// parserOptions returns the options of the parser for the SQL syntax of the dialect.
+func parserOptions(dialect string) parser.Options {
+	return parser.Options{
+		BackslashEscapes: dialect == store.MySQL || dialect == store.ClickHouse,
+	}
+}
+
+// Parse reads and parses the migration file into up and down statements.
+func (s sqlPreparer) Parse() (*parser.ParsedMigration, error) {
+	if !s.split {
+		return parseFile(s.fsys, s.filePath, func(r io.Reader) (*parser.ParsedMigration, error) {
+			return parser.ParseMigration(r, s.opts)
+		})
+	}
+
+	parsed, err := parseFile(s.fsys, s.filePath, func(r io.Reader) (*parser.ParsedMigration, error) {
+		return parser.ParseDirection(r, false, s.opts)
+	})
+	if err != nil || s.downFilePath == "" {
+		return parsed, err
+	}
+
+	down, err := parseFile(s.fsys, s.downFilePath, func(r io.Reader) (*parser.ParsedMigration, error) {
+		return parser.ParseDirection(r, true, s.opts)
+	})
+	if err != nil {
+		return nil, err
+	}
+	parsed.DownStatements = down.DownStatements
+	parsed.DisableTransactionDown = down.DisableTransactionDown
+
+	return parsed, nil
+}
+
+// parseFile reads the file and parses it with parse, the file path is added to parse errors.
+func parseFile(
+	fsys fs.FS, filePath string, parse func(r io.Reader) (*parser.ParsedMigration, error),
+) (*parser.ParsedMigration, error) {
+	file, err := fsys.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file at path %s: %w", filePath, err)
 	}
 	defer func() {
 		_ = file.Close()
 	}()
 
-	parsed, err := parser.ParseMigration(file)
-	if parsed == nil || err != nil {
-		return nil, fmt.Errorf("failed to parse migration %s: %w", s.filePath, err)
+	parsed, err := parse(file)
+	if err != nil {
+		var parseErr *parser.ParseError
+		if errors.As(err, &parseErr) {
+			parseErr.File = filePath
+			return nil, parseErr
+		}
+		return nil, fmt.Errorf("failed to parse migration %s: %w", filePath, err)
 	}
 
+	return parsed, nil
+}
+
+func newSQLExecutors(parsed *parser.ParsedMigration) *executors {
 	var container *executors
 	if parsed.DisableTransactionUp || parsed.DisableTransactionDown {
 		e := executor.NewSQLExecutorNoTx(parsed.UpStatements, parsed.DownStatements)
@@ -41,5 +118,5 @@ func (s sqlPreparer) Prepare() (*executors, error) {
 		container = newExecutorTxContainer(e)
 	}
 
-	return container, nil
+	return container
 }
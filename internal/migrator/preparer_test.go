@@ -4,8 +4,10 @@ import (
 	"os"
 	"sync"
 	"testing"
+	"testing/fstest"
 
 	"github.com/evgodev/migratory/internal/migrator/executor"
+	"github.com/evgodev/migratory/internal/migrator/parser"
 	"github.com/evgodev/migratory/internal/require"
 )
 
@@ -87,7 +89,7 @@ func TestSQLPreparerPrepare(t *testing.T) {
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			preparer := newSQLPreparer(test.fields.sourcePath)
+			preparer := newSQLPreparer(osFS{}, test.fields.sourcePath, parser.Options{})
 
 			if test.createTestFile != nil {
 				test.createTestFile(t, testFiles)
@@ -106,6 +108,60 @@ func TestSQLPreparerPrepare(t *testing.T) {
 	}
 }
 
+func TestSQLPreparerDialectEscapes(t *testing.T) {
+	t.Parallel()
+	const (
+		escapedQuote      = `INSERT INTO a VALUES ('it\'s; fine');`
+		escapedBackslash  = `INSERT INTO a VALUES ('c:\\'); INSERT INTO a VALUES ('d');`
+		trailingBackslash = `INSERT INTO a VALUES ('a\'); INSERT INTO a VALUES ('b');`
+	)
+
+	tests := map[string]struct {
+		dialect string
+		sql     string
+		want    []string
+		wantErr error
+	}{
+		"postgres escaped quote": {dialect: Postgres, sql: escapedQuote, wantErr: parser.ErrUnterminatedQuote},
+		"postgres trailing backslash": {
+			dialect: Postgres, sql: trailingBackslash,
+			want: []string{`INSERT INTO a VALUES ('a\');` + "\n", `INSERT INTO a VALUES ('b');` + "\n"},
+		},
+		"sqlite escaped quote": {dialect: SQLite, sql: escapedQuote, wantErr: parser.ErrUnterminatedQuote},
+		"sqlite trailing backslash": {
+			dialect: SQLite, sql: trailingBackslash,
+			want: []string{`INSERT INTO a VALUES ('a\');` + "\n", `INSERT INTO a VALUES ('b');` + "\n"},
+		},
+		"mysql escaped quote": {dialect: MySQL, sql: escapedQuote, want: []string{escapedQuote + "\n"}},
+		"mysql escaped backslash": {
+			dialect: MySQL, sql: escapedBackslash,
+			want: []string{`INSERT INTO a VALUES ('c:\\');` + "\n", `INSERT INTO a VALUES ('d');` + "\n"},
+		},
+		"mysql trailing backslash": {dialect: MySQL, sql: trailingBackslash, wantErr: parser.ErrUnterminatedQuote},
+		"clickhouse escaped quote": {dialect: ClickHouse, sql: escapedQuote, want: []string{escapedQuote + "\n"}},
+		"clickhouse escaped backslash": {
+			dialect: ClickHouse, sql: escapedBackslash,
+			want: []string{`INSERT INTO a VALUES ('c:\\');` + "\n", `INSERT INTO a VALUES ('d');` + "\n"},
+		},
+		"clickhouse trailing backslash": {dialect: ClickHouse, sql: trailingBackslash, wantErr: parser.ErrUnterminatedQuote},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			fsys := fstest.MapFS{
+				"1_insert.sql": {Data: []byte("-- +migrate up\n" + test.sql + "\n-- +migrate down\nDELETE FROM a;\n")},
+			}
+			parsed, err := newSQLPreparer(fsys, "1_insert.sql", parserOptions(test.dialect)).Parse()
+			require.ErrorIs(t, err, test.wantErr, "Parse() error")
+			if test.wantErr == nil {
+				require.Equal(t, parsed.UpStatements, test.want, "Parse() up statements")
+			}
+		})
+	}
+}
+
 type tmpFiles struct {
 	mu        sync.Mutex
 	fileNames []string
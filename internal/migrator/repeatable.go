@@ -0,0 +1,183 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/evgodev/migratory/internal/migrator/store"
+)
+
+// repeatablePrefix starts file names of repeatable migrations, e.g. R_refresh_views.sql.
+const repeatablePrefix = "R" + separator
+
+// IsRepeatableFile reports whether the file is a repeatable migration, e.g. R_refresh_views.sql.
+func IsRepeatableFile(filePath string) bool {
+	base := filepath.Base(filePath)
+	return strings.HasPrefix(base, repeatablePrefix) && filepath.Ext(base) == ".sql"
+}
+
+// SeekRepeatables finds repeatable migrations (R_<name>.sql) in the given directory of the OS file system.
+// Repeatable migrations have no ID, they are re-applied after versioned migrations whenever their checksum
+// changes, see WithRepeatables. Returns a list ordered by name, in which they are applied.
+func SeekRepeatables(dir, dialect string) (Migrations, error) {
+	return SeekRepeatablesFS(osFS{}, dir, dialect)
+}
+
+// SeekRepeatablesFS finds repeatable migrations (R_<name>.sql) in the given directory of fsys, e.g. embed.FS.
+// Like other SQL migrations, they are parsed lazily in the syntax of the dialect. Returns a list ordered by name.
+func SeekRepeatablesFS(fsys fs.FS, dir, dialect string) (Migrations, error) {
+	filePaths, err := fs.Glob(fsys, joinPath(fsys, dir, repeatablePrefix+fileNamePattern))
+	if err != nil {
+		return nil, errors.Join(ErrGlobMigrations, err)
+	}
+
+	repeatables := make(Migrations, 0, len(filePaths))
+	for _, filePath := range filePaths {
+		name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(filePath), repeatablePrefix), ".sql")
+		repeatable := newSQLMigration(0, name, fsys, filePath, parserOptions(dialect))
+		repeatable.repeatable = true
+		repeatables = append(repeatables, repeatable)
+	}
+
+	sort.Slice(repeatables, func(i, j int) bool {
+		return repeatables[i].Name() < repeatables[j].Name()
+	})
+
+	return repeatables, nil
+}
+
+// WithRepeatables makes Up apply the repeatable migrations found by SeekRepeatables after versioned ones,
+// if they were never applied or changed since their last run. UpTo and UpLimit don't apply them.
+func WithRepeatables(repeatables Migrations) Option {
+	return func(m *Migrator) {
+		m.repeatables = repeatables
+	}
+}
+
+// pendingRepeatables returns repeatable migrations never applied or changed since their last run.
+func (m Migrator) pendingRepeatables(ctx context.Context, db *sql.DB) (Migrations, error) {
+	if len(m.repeatables) == 0 {
+		return nil, nil
+	}
+
+	repeatables := make(Migrations, len(m.repeatables))
+	copy(repeatables, m.repeatables)
+	if err := PrepareMigrations(repeatables); err != nil {
+		return nil, err
+	}
+
+	applied, err := m.store.ListRepeatables(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repeatable migrations from store: %w", err)
+	}
+	checksums := make(map[string]string, len(applied))
+	for _, r := range applied {
+		checksums[r.Name] = r.Checksum
+	}
+
+	var pending Migrations
+	for _, repeatable := range repeatables {
+		checksum, err := repeatable.Checksum()
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate checksum of repeatable migration %s: %w",
+				repeatable.Name(), err)
+		}
+		if applied, exists := checksums[repeatable.Name()]; !exists || applied != checksum {
+			pending = append(pending, repeatable)
+		}
+	}
+
+	return pending, nil
+}
+
+func (m Migrator) upRepeatable(ctx context.Context, migration Migration, db *sql.DB) error {
+	noTx, err := m.chooseExecutor(&migration)
+	if err != nil {
+		return err
+	}
+
+	if m.plan != nil {
+		m.plan.add(&migration, store.DirectionUp, noTx, 0)
+		return nil
+	}
+
+	return m.run(ctx, &migration, db, store.DirectionUp, noTx, func(ctx context.Context) error {
+		if noTx {
+			return m.upRepeatableNoTx(ctx, migration, db)
+		}
+		return m.upRepeatableTx(ctx, migration, db)
+	})
+}
+
+func (m Migrator) upRepeatableTx(ctx context.Context, migration Migration, db *sql.DB) error {
+	checksum, err := migration.Checksum()
+	if err != nil {
+		return fmt.Errorf("failed to calculate checksum: %w", err)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	info := newHookInfo(&migration, store.DirectionUp, db, tx)
+	if err = callHook(ctx, m.hooks.BeforeEach, "before each", info); err != nil {
+		return rollbackTx(tx, err)
+	}
+
+	if err = migration.UpTx(ctx, tx); err != nil {
+		return rollbackTx(tx, fmt.Errorf("failed to up migration: %w", err))
+	}
+
+	if err = m.store.InsertRepeatable(ctx, tx, migration.Name(), checksum); err != nil {
+		return rollbackTx(tx, fmt.Errorf("failed to insert repeatable migration in table: %w", err))
+	}
+
+	if err = callHook(ctx, m.hooks.AfterEach, "after each", info); err != nil {
+		return rollbackTx(tx, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// upRepeatableNoTx runs the repeatable migration without transaction. Unlike versioned migrations,
+// an interrupted run is not marked as dirty: the migration is not recorded, so it's run again by the next Up.
+func (m Migrator) upRepeatableNoTx(ctx context.Context, migration Migration, db *sql.DB) error {
+	checksum, err := migration.Checksum()
+	if err != nil {
+		return fmt.Errorf("failed to calculate checksum: %w", err)
+	}
+
+	info := newHookInfo(&migration, store.DirectionUp, db, nil)
+	if err = callHook(ctx, m.hooks.BeforeEach, "before each", info); err != nil {
+		return err
+	}
+
+	if err = migration.UpDB(ctx, db); err != nil {
+		return fmt.Errorf("failed to up migration: %w", err)
+	}
+
+	// Some drivers (e.g. ClickHouse) support inserts in a transaction only.
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if err = m.store.InsertRepeatable(ctx, tx, migration.Name(), checksum); err != nil {
+		return rollbackTx(tx, fmt.Errorf("failed to insert repeatable migration in table: %w", err))
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return callAfterRecorded(ctx, m.hooks.AfterEach, info)
+}
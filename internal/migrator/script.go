@@ -0,0 +1,126 @@
+package migrator
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/evgodev/migratory/internal/migrator/store"
+)
+
+var ErrGoMigrationInScript = errors.New("go migration can't be written to a SQL script")
+
+// ScriptOptions selects the migrations written by WriteScript.
+type ScriptOptions struct {
+	// From and To select migrations with From < ID <= To: the database is at version From before the script
+	// is run, and at version To after it. When Down is set, the script moves the database from From down to To.
+	From, To int64
+	// Down makes the script roll back the migrations, starting from the last one, instead of applying them.
+	Down bool
+}
+
+// WriteScript writes the SQL migrations selected by opts into one script to be run by hand, e.g. by a DBA.
+// The script starts with the dialect query creating the migrations table if not exists. Every migration
+// is followed by the query recording it in (or deleting it from) the migrations table, and transactional
+// migrations are wrapped in BEGIN and COMMIT together with it, so the migrations table stays consistent
+// after the script is run. Go migrations can't be written, ErrGoMigrationInScript is returned if any is selected.
+func WriteScript(
+	w io.Writer, dialect, tableName string, migrations Migrations, opts ScriptOptions, migratorOpts ...Option,
+) error {
+	m, err := newMigrator(dialect, tableName, migratorOpts)
+	if err != nil {
+		return err
+	}
+
+	selected := selectScriptMigrations(migrations, opts)
+	if err = PrepareMigrations(selected); err != nil {
+		return err
+	}
+
+	direction := store.DirectionUp
+	if opts.Down {
+		direction = store.DirectionDown
+		for i := range selected {
+			if selected[i].IsIrreversible() {
+				return fmt.Errorf("migration with ID %d: %w", selected[i].ID(), ErrIrreversible)
+			}
+		}
+	}
+	_, err = fmt.Fprintf(w, "-- migratory: %s, %d migration(s)\n%s\n", direction, len(selected),
+		m.store.CreateMigrationsTableScript())
+	if err != nil {
+		return err
+	}
+
+	for i := range selected {
+		if err = m.writeScriptMigration(w, &selected[i], direction); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// selectScriptMigrations returns migrations with From < ID <= To in the order they are run.
+func selectScriptMigrations(migrations Migrations, opts ScriptOptions) Migrations {
+	from, to := opts.From, opts.To
+	if opts.Down {
+		from, to = to, from
+	}
+
+	var selected Migrations
+	for _, migration := range migrations {
+		if migration.ID() > from && migration.ID() <= to {
+			selected = append(selected, migration)
+		}
+	}
+
+	sort.Slice(selected, func(i, j int) bool {
+		if opts.Down {
+			return selected[i].ID() > selected[j].ID()
+		}
+		return selected[i].ID() < selected[j].ID()
+	})
+
+	return selected
+}
+
+func (m Migrator) writeScriptMigration(w io.Writer, migration *Migration, direction string) error {
+	if migration.parsed == nil {
+		return fmt.Errorf("%w: migration with ID %d (%s)", ErrGoMigrationInScript, migration.ID(), migration.Name())
+	}
+
+	noTx, err := m.chooseExecutor(migration)
+	if err != nil {
+		return err
+	}
+
+	statements := migration.parsed.UpStatements
+	record := m.store.InsertMigrationScript(migration.Name(), migration.ID(), statementsChecksum(migration.parsed))
+	if direction == store.DirectionDown {
+		statements = migration.parsed.DownStatements
+		record = m.store.DeleteMigrationScript(migration.ID())
+	}
+
+	useTx := !noTx && m.store.SupportsTransactions()
+	txMode := "no transaction"
+	if useTx {
+		txMode = "transaction"
+	}
+
+	script := fmt.Sprintf("\n-- %d %s: %s, %s\n", migration.ID(), migration.Name(), direction, txMode)
+	if useTx {
+		script += "BEGIN;\n"
+	}
+	for _, statement := range statements {
+		script += statement
+	}
+	script += record + "\n"
+	if useTx {
+		script += "COMMIT;\n"
+	}
+
+	_, err = io.WriteString(w, script)
+	return err
+}
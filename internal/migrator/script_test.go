@@ -0,0 +1,140 @@
+package migrator
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/evgodev/migratory/internal/migrator/parser"
+	"github.com/evgodev/migratory/internal/require"
+)
+
+func TestWriteScript(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"1_create_a.sql": {Data: []byte("-- +migrate up\nCREATE TABLE a (id int);\n-- +migrate down\nDROP TABLE a;\n")},
+		"2_insert_a.sql": {Data: []byte("-- +migrate up\nINSERT INTO a VALUES (1);\n-- +migrate down\nDELETE FROM a;\n")},
+		"3_index_a.sql": {
+			Data: []byte("-- +migrate up no_transaction\nCREATE INDEX idx ON a (id);\n-- +migrate down\nDROP INDEX idx;\n"),
+		},
+	}
+	migrations := Migrations{
+		newSQLMigration(1, "create_a", fsys, "1_create_a.sql", parser.Options{}),
+		newSQLMigration(2, "insert_a", fsys, "2_insert_a.sql", parser.Options{}),
+		newSQLMigration(3, "index_a", fsys, "3_index_a.sql", parser.Options{}),
+	}
+	insertChecksum, err := migrations[1].Checksum()
+	require.NoError(t, err, "migrations[1].Checksum() error")
+	indexChecksum, err := migrations[2].Checksum()
+	require.NoError(t, err, "migrations[2].Checksum() error")
+
+	tests := map[string]struct {
+		dialect string
+		opts    ScriptOptions
+		want    string
+	}{
+		"postgres up": {
+			dialect: Postgres,
+			opts:    ScriptOptions{From: 1, To: 3},
+			want: "-- migratory: up, 2 migration(s)\n" +
+				"CREATE SCHEMA IF NOT EXISTS public;\n" +
+				"CREATE TABLE IF NOT EXISTS public.migrations (\n\t\tid bigint PRIMARY KEY,\n" +
+				"\t\tname VARCHAR(255) NOT NULL,\n\t\tapplied_at timestamp NOT NULL,\n" +
+				"\t\tchecksum VARCHAR(64) NOT NULL DEFAULT '',\n\t\tbaselined BOOLEAN NOT NULL DEFAULT FALSE\n\t);\n" +
+				"\n-- 2 insert_a: up, transaction\nBEGIN;\nINSERT INTO a VALUES (1);\n" +
+				"INSERT INTO public.migrations (id, name, applied_at, checksum) " +
+				"VALUES (2, 'insert_a', now(), '" + insertChecksum + "');\nCOMMIT;\n" +
+				"\n-- 3 index_a: up, no transaction\nCREATE INDEX idx ON a (id);\n" +
+				"INSERT INTO public.migrations (id, name, applied_at, checksum) " +
+				"VALUES (3, 'index_a', now(), '" + indexChecksum + "');\n",
+		},
+		"mysql down": {
+			dialect: MySQL,
+			opts:    ScriptOptions{From: 2, To: 0, Down: true},
+			want: "-- migratory: down, 2 migration(s)\n" +
+				"CREATE TABLE IF NOT EXISTS migrations (\n\t\tid BIGINT PRIMARY KEY,\n" +
+				"\t\tname VARCHAR(255) NOT NULL,\n\t\tapplied_at TIMESTAMP NOT NULL,\n" +
+				"\t\tchecksum VARCHAR(64) NOT NULL DEFAULT '',\n\t\tbaselined BOOLEAN NOT NULL DEFAULT FALSE\n\t);\n" +
+				"\n-- 2 insert_a: down, transaction\nBEGIN;\nDELETE FROM a;\nDELETE FROM migrations WHERE id = 2;\nCOMMIT;\n" +
+				"\n-- 1 create_a: down, no transaction\nDROP TABLE a;\nDELETE FROM migrations WHERE id = 1;\n",
+		},
+		"clickhouse without transactions": {
+			dialect: ClickHouse,
+			opts:    ScriptOptions{From: 2, To: 1, Down: true},
+			want: "-- migratory: down, 1 migration(s)\n" +
+				"CREATE TABLE IF NOT EXISTS migrations (\n\t\tid Int64 PRIMARY KEY,\n" +
+				"\t\tname String NOT NULL,\n\t\tapplied_at timestamp NOT NULL,\n" +
+				"\t\tchecksum String DEFAULT '',\n\t\tbaselined UInt8 DEFAULT 0\n\t)\n" +
+				"\tENGINE = MergeTree() PRIMARY KEY id;\n" +
+				"\n-- 2 insert_a: down, no transaction\nDELETE FROM a;\n" +
+				"ALTER TABLE migrations DELETE WHERE id = 2 SETTINGS mutations_sync = 2;\n",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var b strings.Builder
+			err := WriteScript(&b, test.dialect, "migrations", migrations, test.opts)
+			require.NoError(t, err, "WriteScript(...) error")
+			require.String(t, b.String(), test.want, "WriteScript(...) script")
+		})
+	}
+}
+
+func TestWriteScriptEscapesName(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"1_a.sql": {Data: []byte("-- +migrate up\nSELECT 1;\n-- +migrate down\nSELECT 2;\n")},
+	}
+	migrations := Migrations{newSQLMigration(1, `it's_c:\dir`, fsys, "1_a.sql", parser.Options{})}
+
+	tests := map[string]struct {
+		dialect  string
+		wantName string
+	}{
+		"postgres":   {dialect: Postgres, wantName: `'it''s_c:\dir'`},
+		"sqlite":     {dialect: SQLite, wantName: `'it''s_c:\dir'`},
+		"mysql":      {dialect: MySQL, wantName: `'it''s_c:\\dir'`},
+		"clickhouse": {dialect: ClickHouse, wantName: `'it''s_c:\\dir'`},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var b strings.Builder
+			err := WriteScript(&b, test.dialect, "migrations", migrations, ScriptOptions{To: 1})
+			require.NoError(t, err, "WriteScript(...) error")
+			require.Bool(t, strings.Contains(b.String(), "VALUES (1, "+test.wantName+", "), true,
+				"WriteScript(...) must write the name as "+test.wantName)
+		})
+	}
+}
+
+func TestWriteScriptGoMigration(t *testing.T) {
+	t.Parallel()
+	var b strings.Builder
+	err := WriteScript(&b, SQLite, "migrations", Migrations{NewGoMigration(1, "go", nil, nil)}, ScriptOptions{To: 1})
+	require.ErrorIs(t, err, ErrGoMigrationInScript, "WriteScript(...) error")
+}
+
+func TestWriteScriptDoesNotLog(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"1_create_a.sql": {Data: []byte("-- +migrate up\nCREATE TABLE a (id int);\n-- +migrate down\nDROP TABLE a;\n")},
+	}
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+
+	var b strings.Builder
+	err := WriteScript(&b, MySQL, "migrations", Migrations{newSQLMigration(1, "create_a", fsys, "1_create_a.sql",
+		parser.Options{})}, ScriptOptions{To: 1}, WithLogger(logger))
+	require.NoError(t, err, "WriteScript(...) error")
+	require.Bool(t, strings.Contains(b.String(), "-- 1 create_a: up, no transaction\n"), true,
+		"WriteScript(...) must write DDL migration of MySQL without transaction")
+	require.String(t, logs.String(), "", "WriteScript(...) must not warn about migrations it doesn't run")
+}
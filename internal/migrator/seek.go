@@ -3,11 +3,13 @@ package migrator
 import (
 	"errors"
 	"fmt"
-	"os"
+	"io/fs"
 	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
+
+	"github.com/evgodev/migratory/internal/migrator/parser"
 )
 
 const (
@@ -22,8 +24,21 @@ var (
 	ErrDirectoryCheck   = errors.New("unable to check directory existence")
 	ErrNoSeparator      = errors.New("no separator found in file name")
 	ErrParseID          = errors.New("unable to parse ID in file name")
+	ErrNoUpFile         = errors.New("down migration file has no up file")
+)
+
+// Suffixes of migrations with up and down statements in separate files, e.g. 001_users.up.sql.
+const (
+	upFileSuffix   = ".up"
+	downFileSuffix = ".down"
 )
 
+// splitMigrationFiles are the up and down files of a migration, the down file may be missing.
+type splitMigrationFiles struct {
+	name             string
+	upPath, downPath string
+}
+
 // ParseMigrationFileName parses a given migration file name into its ID and name.
 func ParseMigrationFileName(fileName string) (id int64, migrationName string, err error) {
 	base := filepath.Base(fileName)
@@ -44,19 +59,27 @@ func ParseMigrationFileName(fileName string) (id int64, migrationName string, er
 	return id, migrationName, nil
 }
 
-// SeekMigrations identifies and parses migration files in the given directory using the provided file system interface.
+// SeekMigrations identifies and parses migration files in the given directory of the OS file system.
+// Files are parsed in the SQL syntax of the dialect, e.g. backslash escapes in MySQL strings.
+// Returns a sorted list of migration objects or an error if the directory or files are invalid.
+func SeekMigrations(dir, dialect string) (Migrations, error) {
+	return SeekMigrationsFS(osFS{}, dir, dialect)
+}
+
+// SeekMigrationsFS identifies and parses migration files in the given directory of fsys, e.g. embed.FS.
+// Migrations keep a reference to fsys, since SQL files are read and parsed lazily when they are applied.
 // Returns a sorted list of migration objects or an error if the directory or files are invalid.
-func SeekMigrations(dir string) (Migrations, error) {
-	if _, err := os.Stat(dir); err != nil {
+func SeekMigrationsFS(fsys fs.FS, dir, dialect string) (Migrations, error) {
+	if _, err := fs.Stat(fsys, dir); err != nil {
 		return nil, errors.Join(ErrDirectoryCheck, err)
 	}
 
-	migrationFiles, err := findMigrationFiles(dir)
+	migrationFiles, err := findMigrationFiles(fsys, dir)
 	if err != nil {
 		return nil, err
 	}
 
-	migrations, err := parseMigrationFiles(migrationFiles)
+	migrations, err := parseMigrationFiles(fsys, migrationFiles, parserOptions(dialect))
 	if err != nil {
 		return nil, err
 	}
@@ -68,8 +91,8 @@ func SeekMigrations(dir string) (Migrations, error) {
 	return migrations, nil
 }
 
-func findMigrationFiles(dir string) ([]string, error) {
-	migrationFiles, err := filepath.Glob(filepath.Join(dir, fileNamePattern))
+func findMigrationFiles(fsys fs.FS, dir string) ([]string, error) {
+	migrationFiles, err := fs.Glob(fsys, joinPath(fsys, dir, fileNamePattern))
 	if err != nil {
 		return nil, errors.Join(ErrGlobMigrations, err)
 	}
@@ -81,23 +104,79 @@ func findMigrationFiles(dir string) ([]string, error) {
 	return migrationFiles, nil
 }
 
-func parseMigrationFiles(filePaths []string) (Migrations, error) {
+// parseMigrationFiles creates migrations of the files. Files with up and down statements separated
+// by commands are migrations on their own, while .up.sql and .down.sql files are paired by ID.
+func parseMigrationFiles(fsys fs.FS, filePaths []string, opts parser.Options) (Migrations, error) {
 	var migrations Migrations
 	uniqueIDMap := make(map[int64]struct{}, len(filePaths))
+	splitFiles := make(map[int64]*splitMigrationFiles)
 
 	for _, filePath := range filePaths {
+		if IsCallbackFile(filePath) || IsRepeatableFile(filePath) {
+			continue
+		}
+
 		id, name, err := ParseMigrationFileName(filePath)
 		if err != nil {
 			return nil, fmt.Errorf("file %s doesn't match the migration pattern: %w", filePath, err)
 		}
 
+		if isSplitFile(name) {
+			if err = addSplitFile(splitFiles, id, name, filePath); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
 		if _, exists := uniqueIDMap[id]; exists {
 			return nil, fmt.Errorf("migration ID %d is duplicated: %w", id, ErrDuplicatedID)
 		}
 
 		uniqueIDMap[id] = struct{}{}
-		migrations = append(migrations, NewSQLMigration(id, name, filePath))
+		migrations = append(migrations, newSQLMigration(id, name, fsys, filePath, opts))
+	}
+
+	for id, files := range splitFiles {
+		if _, exists := uniqueIDMap[id]; exists {
+			return nil, fmt.Errorf("migration ID %d is duplicated: %w", id, ErrDuplicatedID)
+		}
+		if files.upPath == "" {
+			return nil, fmt.Errorf("migration file %s: %w", files.downPath, ErrNoUpFile)
+		}
+
+		migrations = append(migrations, newSplitSQLMigration(id, files.name, fsys, files.upPath, files.downPath, opts))
 	}
 
 	return migrations, nil
 }
+
+func isSplitFile(name string) bool {
+	return strings.HasSuffix(name, upFileSuffix) || strings.HasSuffix(name, downFileSuffix)
+}
+
+// addSplitFile pairs the up or down file with the other file of the migration with the same ID.
+func addSplitFile(splitFiles map[int64]*splitMigrationFiles, id int64, name, filePath string) error {
+	files, exists := splitFiles[id]
+	if !exists {
+		files = &splitMigrationFiles{}
+		splitFiles[id] = files
+	}
+
+	if upName, isUp := strings.CutSuffix(name, upFileSuffix); isUp {
+		if files.upPath != "" {
+			return fmt.Errorf("migration ID %d is duplicated: %w", id, ErrDuplicatedID)
+		}
+		files.name, files.upPath = upName, filePath
+		return nil
+	}
+
+	if files.downPath != "" {
+		return fmt.Errorf("migration ID %d is duplicated: %w", id, ErrDuplicatedID)
+	}
+	files.downPath = filePath
+	if files.name == "" {
+		files.name = strings.TrimSuffix(name, downFileSuffix)
+	}
+
+	return nil
+}
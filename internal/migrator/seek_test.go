@@ -2,6 +2,7 @@ package migrator
 
 import (
 	"testing"
+	"testing/fstest"
 
 	"github.com/evgodev/migratory/internal/require"
 )
@@ -41,3 +42,92 @@ func TestParseMigrationFileName(t *testing.T) {
 		})
 	}
 }
+
+func TestSeekMigrationsFS(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"migrations/02_second.sql":       {Data: []byte("-- +migrate up\nSELECT 2;\n-- +migrate down\nSELECT -2;\n")},
+		"migrations/01_first.sql":        {Data: []byte("-- +migrate up no_transaction\nSELECT 1;\n")},
+		"migrations/readme.md":           {Data: []byte("not a migration")},
+		"migrations/" + CallbackAfterAll: {Data: []byte("-- +migrate up\nSELECT 3;\n")},
+		"migrations/R_views.sql":         {Data: []byte("-- +migrate up\nSELECT 4;\n")},
+	}
+
+	migrations, err := SeekMigrationsFS(fsys, "migrations", Postgres)
+	require.NoError(t, err, "SeekMigrationsFS(...) error")
+	require.Int(t, len(migrations), 2, "SeekMigrationsFS(...) migrations count")
+	require.Int64(t, migrations[0].ID(), 1, "SeekMigrationsFS(...) first migration ID")
+	require.String(t, migrations[1].Name(), "second", "SeekMigrationsFS(...) second migration name")
+
+	// SQL files are parsed lazily from the same fs.FS
+	noTx, err := migrations[0].ChooseExecutor()
+	require.NoError(t, err, "migration.ChooseExecutor() error")
+	require.Bool(t, noTx, true, "migration.ChooseExecutor() no transaction")
+
+	repeatables, err := SeekRepeatablesFS(fsys, "migrations", Postgres)
+	require.NoError(t, err, "SeekRepeatablesFS(...) error")
+	require.Int(t, len(repeatables), 1, "SeekRepeatablesFS(...) repeatables count")
+	require.String(t, repeatables[0].Name(), "views", "SeekRepeatablesFS(...) repeatable name")
+	require.Bool(t, repeatables[0].IsRepeatable(), true, "SeekRepeatablesFS(...) repeatable")
+
+	_, err = SeekMigrationsFS(fsys, "unknown", Postgres)
+	require.ErrorIs(t, err, ErrDirectoryCheck, "SeekMigrationsFS(...) unknown directory")
+
+	_, err = SeekMigrationsFS(fstest.MapFS{"migrations/readme.md": {}}, "migrations", Postgres)
+	require.ErrorIs(t, err, ErrNoMigrationFiles, "SeekMigrationsFS(...) no migrations")
+}
+
+func TestSeekMigrationsFSSplitFiles(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"migrations/001_users.up.sql":   {Data: []byte("CREATE TABLE users (id INT);\n")},
+		"migrations/001_users.down.sql": {Data: []byte("DROP TABLE users;\n")},
+		"migrations/002_seed.up.sql":    {Data: []byte("INSERT INTO users VALUES (1);\nINSERT INTO users VALUES (2);\n")},
+		"migrations/003_orders.sql":     {Data: []byte("-- +migrate up\nCREATE TABLE orders (id INT);\n")},
+	}
+
+	migrations, err := SeekMigrationsFS(fsys, "migrations", Postgres)
+	require.NoError(t, err, "SeekMigrationsFS(...) error")
+	require.Int(t, len(migrations), 3, "SeekMigrationsFS(...) migrations count")
+	require.String(t, migrations[0].Name(), "users", "SeekMigrationsFS(...) split migration name")
+
+	require.NoError(t, PrepareMigrations(migrations), "PrepareMigrations(...) error")
+	require.Equal(t, migrations[0].parsed.DownStatements, []string{"DROP TABLE users;\n"}, "down statements")
+	require.Int(t, len(migrations[1].parsed.UpStatements), 2, "up statements of migration without down file")
+	require.Int(t, len(migrations[1].parsed.DownStatements), 0, "down statements of migration without down file")
+	require.Bool(t, migrations[0].IsIrreversible(), false, "migration with down file must be reversible")
+	require.Bool(t, migrations[1].IsIrreversible(), true, "migration without down file must be irreversible")
+	require.Bool(t, migrations[2].IsIrreversible(), false, "migration with up and down commands must be reversible")
+
+	tests := map[string]struct {
+		fsys    fstest.MapFS
+		wantErr error
+	}{
+		"down file only": {
+			fsys:    fstest.MapFS{"m/001_users.down.sql": {Data: []byte("DROP TABLE users;\n")}},
+			wantErr: ErrNoUpFile,
+		},
+		"split and single files": {
+			fsys: fstest.MapFS{
+				"m/001_users.up.sql": {Data: []byte("CREATE TABLE users (id INT);\n")},
+				"m/001_users.sql":    {Data: []byte("-- +migrate up\nCREATE TABLE users (id INT);\n")},
+			},
+			wantErr: ErrDuplicatedID,
+		},
+		"two up files": {
+			fsys: fstest.MapFS{
+				"m/001_users.up.sql":  {Data: []byte("CREATE TABLE users (id INT);\n")},
+				"m/001_people.up.sql": {Data: []byte("CREATE TABLE people (id INT);\n")},
+			},
+			wantErr: ErrDuplicatedID,
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			_, err := SeekMigrationsFS(test.fsys, "m", Postgres)
+			require.ErrorIs(t, err, test.wantErr, "SeekMigrationsFS(...) error")
+		})
+	}
+}
@@ -12,17 +12,29 @@ func (c *clickhouseQueryBuilder) MigrationsTableExists(tableName string) string
 }
 
 func (c *clickhouseQueryBuilder) CreateMigrationsTable(tableName string) string {
-	q := `CREATE TABLE %s (
+	q := `CREATE TABLE IF NOT EXISTS %s (
 		id Int64 PRIMARY KEY,
 		name String NOT NULL,
-		applied_at timestamp NOT NULL
+		applied_at timestamp NOT NULL,
+		checksum String DEFAULT '',
+		baselined UInt8 DEFAULT 0
 	)
 	ENGINE = MergeTree() PRIMARY KEY id;`
 	return fmt.Sprintf(q, tableName)
 }
 
 func (c *clickhouseQueryBuilder) InsertMigration(tableName string) string {
-	q := `INSERT INTO %s (id, name, applied_at) VALUES (?, ?, now())`
+	q := `INSERT INTO %s (id, name, applied_at, checksum) VALUES (?, ?, now(), ?)`
+	return fmt.Sprintf(q, tableName)
+}
+
+func (c *clickhouseQueryBuilder) InsertBaselinedMigration(tableName string) string {
+	q := `INSERT INTO %s (id, name, applied_at, checksum, baselined) VALUES (?, ?, now(), ?, 1)`
+	return fmt.Sprintf(q, tableName)
+}
+
+func (c *clickhouseQueryBuilder) InsertImportedMigration(tableName string) string {
+	q := `INSERT INTO %s (id, name, applied_at, checksum) VALUES (?, ?, ?, ?)`
 	return fmt.Sprintf(q, tableName)
 }
 
@@ -32,11 +44,132 @@ func (c *clickhouseQueryBuilder) DeleteMigration(tableName string) string {
 }
 
 func (c *clickhouseQueryBuilder) ListMigrations(tableName string) string {
+	q := `SELECT id, name, applied_at, checksum, baselined FROM %s ORDER BY id ASC`
+	return fmt.Sprintf(q, tableName)
+}
+
+func (c *clickhouseQueryBuilder) ListLegacyMigrations(tableName string) string {
 	q := `SELECT id, name, applied_at FROM %s ORDER BY id ASC`
 	return fmt.Sprintf(q, tableName)
 }
 
+func (c *clickhouseQueryBuilder) SelectColumns(tableName string) string {
+	q := `SELECT * FROM %s LIMIT 0`
+	return fmt.Sprintf(q, tableName)
+}
+
+func (c *clickhouseQueryBuilder) AddChecksumColumn(tableName string) string {
+	q := `ALTER TABLE %s ADD COLUMN IF NOT EXISTS checksum String DEFAULT ''`
+	return fmt.Sprintf(q, tableName)
+}
+
+func (c *clickhouseQueryBuilder) AddBaselinedColumn(tableName string) string {
+	q := `ALTER TABLE %s ADD COLUMN IF NOT EXISTS baselined UInt8 DEFAULT 0`
+	return fmt.Sprintf(q, tableName)
+}
+
 func (c *clickhouseQueryBuilder) SelectLastMigrationID(tableName string) string {
 	q := `SELECT id FROM %s ORDER BY id DESC LIMIT 1`
 	return fmt.Sprintf(q, tableName)
 }
+
+func (c *clickhouseQueryBuilder) CreateLockTable(lockTableName string) string {
+	q := `CREATE TABLE IF NOT EXISTS %s (
+		id Int64,
+		owner String,
+		locked_at DateTime
+	)
+	ENGINE = MergeTree() ORDER BY id;`
+	return fmt.Sprintf(q, lockTableName)
+}
+
+func (c *clickhouseQueryBuilder) InsertLock(lockTableName string) string {
+	q := `INSERT INTO %s (id, owner, locked_at) VALUES (?, ?, ?)`
+	return fmt.Sprintf(q, lockTableName)
+}
+
+func (c *clickhouseQueryBuilder) SelectLockOwner(lockTableName string) string {
+	q := `SELECT owner FROM %s ORDER BY locked_at ASC, owner ASC LIMIT 1`
+	return fmt.Sprintf(q, lockTableName)
+}
+
+// RefreshLock updates locked_at, which is not a key column, so it can be changed by a mutation.
+func (c *clickhouseQueryBuilder) RefreshLock(lockTableName string) string {
+	q := `ALTER TABLE %s UPDATE locked_at = ? WHERE owner = ? SETTINGS mutations_sync = 2;`
+	return fmt.Sprintf(q, lockTableName)
+}
+
+func (c *clickhouseQueryBuilder) DeleteLock(lockTableName string) string {
+	q := `ALTER TABLE %s DELETE WHERE owner = ? SETTINGS mutations_sync = 2;`
+	return fmt.Sprintf(q, lockTableName)
+}
+
+func (c *clickhouseQueryBuilder) DeleteStaleLocks(lockTableName string) string {
+	q := `ALTER TABLE %s DELETE WHERE locked_at < ? SETTINGS mutations_sync = 2;`
+	return fmt.Sprintf(q, lockTableName)
+}
+
+func (c *clickhouseQueryBuilder) CreateDirtyTable(dirtyTableName string) string {
+	q := `CREATE TABLE IF NOT EXISTS %s (
+		id Int64,
+		name String,
+		direction String,
+		statement Int64,
+		checksum String,
+		created_at DateTime
+	)
+	ENGINE = MergeTree() ORDER BY (id, created_at);`
+	return fmt.Sprintf(q, dirtyTableName)
+}
+
+func (c *clickhouseQueryBuilder) InsertDirtyMark(dirtyTableName string) string {
+	q := `INSERT INTO %s (id, name, direction, statement, checksum, created_at)
+		VALUES (?, ?, ?, ?, ?, now())`
+	return fmt.Sprintf(q, dirtyTableName)
+}
+
+func (c *clickhouseQueryBuilder) SelectDirtyMark(dirtyTableName string) string {
+	q := `SELECT id, name, direction, statement, checksum, created_at FROM %s
+		ORDER BY statement DESC, created_at DESC LIMIT 1`
+	return fmt.Sprintf(q, dirtyTableName)
+}
+
+func (c *clickhouseQueryBuilder) DeleteDirtyMarks(dirtyTableName string) string {
+	q := `ALTER TABLE %s DELETE WHERE id = ? SETTINGS mutations_sync = 2;`
+	return fmt.Sprintf(q, dirtyTableName)
+}
+
+func (c *clickhouseQueryBuilder) CreateRepeatableTable(repeatableTableName string) string {
+	q := `CREATE TABLE IF NOT EXISTS %s (
+		name String,
+		checksum String,
+		applied_at DateTime
+	)
+	ENGINE = MergeTree() ORDER BY (name, applied_at);`
+	return fmt.Sprintf(q, repeatableTableName)
+}
+
+func (c *clickhouseQueryBuilder) InsertRepeatable(repeatableTableName string) string {
+	q := `INSERT INTO %s (name, checksum, applied_at) VALUES (?, ?, now())`
+	return fmt.Sprintf(q, repeatableTableName)
+}
+
+func (c *clickhouseQueryBuilder) DeleteRepeatable(repeatableTableName string) string {
+	q := `ALTER TABLE %s DELETE WHERE name = ? SETTINGS mutations_sync = 2;`
+	return fmt.Sprintf(q, repeatableTableName)
+}
+
+func (c *clickhouseQueryBuilder) ListRepeatables(repeatableTableName string) string {
+	q := `SELECT name, checksum, applied_at FROM %s ORDER BY name ASC`
+	return fmt.Sprintf(q, repeatableTableName)
+}
+
+// BackslashEscapes reports that a backslash escapes the next character in ClickHouse string literals.
+func (c *clickhouseQueryBuilder) BackslashEscapes() bool {
+	return true
+}
+
+// Transactionless reports that ClickHouse has no SQL transactions.
+func (c *clickhouseQueryBuilder) Transactionless() bool {
+	return true
+}
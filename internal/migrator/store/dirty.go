@@ -0,0 +1,86 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const dirtyTableSuffix = "_dirty"
+
+const (
+	DirectionUp   = "up"
+	DirectionDown = "down"
+)
+
+// DirtyMark is a record of a migration without transaction that was started but not finished.
+type DirtyMark struct {
+	ID        int64
+	Name      string
+	Direction string
+	Statement int    // Number of statements of a SQL migration executed before it was interrupted.
+	Checksum  string // Checksum of the executed statements, see Migration.ExecutedChecksum.
+	CreatedAt time.Time
+}
+
+func (s Store) dirtyTableName() string {
+	return s.tableName + dirtyTableSuffix
+}
+
+// CreateDirtyTable creates the table of dirty marks if not exists.
+func (s Store) CreateDirtyTable(ctx context.Context, db database) error {
+	_, err := db.ExecContext(ctx, s.queryManager.CreateDirtyTable(s.dirtyTableName()))
+	return err
+}
+
+// InsertDirtyMark marks the migration as started. It must be written before a migration without transaction
+// starts, and deleted after it's recorded as applied (rolled back), so an interrupted run leaves a trace.
+// SQL migrations insert a new mark after each executed statement with the checksum of the executed ones,
+// the mark with the most statements wins.
+func (s Store) InsertDirtyMark(
+	ctx context.Context, db *sql.DB, id int64, name, direction string, statement int, checksum string,
+) error {
+	// Some drivers (e.g. ClickHouse) support inserts in a transaction only.
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, s.queryManager.InsertDirtyMark(s.dirtyTableName()), id, name, direction, statement,
+		checksum)
+	if err != nil {
+		if txErr := tx.Rollback(); txErr != nil {
+			return fmt.Errorf("failed to insert dirty mark and rollback transaction: %w; %w", err, txErr)
+		}
+		return fmt.Errorf("failed to insert dirty mark: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// SelectDirtyMark returns the dirty mark, or ErrNoRows if no migration was interrupted.
+func (s Store) SelectDirtyMark(ctx context.Context, db database) (DirtyMark, error) {
+	if s.absent != nil && s.absent.dirty {
+		return DirtyMark{}, ErrNoRows
+	}
+
+	var mark DirtyMark
+	row := db.QueryRowContext(ctx, s.queryManager.SelectDirtyMark(s.dirtyTableName()))
+	err := row.Scan(&mark.ID, &mark.Name, &mark.Direction, &mark.Statement, &mark.Checksum, &mark.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return DirtyMark{}, ErrNoRows
+		}
+		return DirtyMark{}, fmt.Errorf("failed to scan dirty mark: %w", err)
+	}
+
+	return mark, nil
+}
+
+// DeleteDirtyMarks deletes dirty marks of the migration.
+func (s Store) DeleteDirtyMarks(ctx context.Context, db database, id int64) error {
+	_, err := db.ExecContext(ctx, s.queryManager.DeleteDirtyMarks(s.dirtyTableName()), id)
+	return err
+}
@@ -0,0 +1,331 @@
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"time"
+)
+
+const (
+	lockTableSuffix = "_lock"
+	lockRowID       = 1
+
+	DefaultLockTimeout       = 5 * time.Minute
+	DefaultLockTTL           = 30 * time.Minute
+	DefaultLockRetryInterval = time.Second
+
+	// lockRefreshesPerTTL is how many times a held lock row is refreshed within TTL,
+	// so that a few failed refreshes don't make it stale.
+	lockRefreshesPerTTL = 3
+)
+
+var (
+	ErrLockUnsupported = errors.New("migrations lock is not supported by dialect")
+	ErrLockTimeout     = errors.New("timed out waiting for migrations lock")
+	ErrLockNotHeld     = errors.New("migrations lock is not held")
+	ErrLockNotFound    = errors.New("migrations lock was taken over or removed")
+)
+
+// Locker guards migrations from being applied by several processes at once.
+type Locker interface {
+	Lock(ctx context.Context) error
+	Unlock(ctx context.Context) error
+}
+
+// LockOptions configures how a lock is acquired.
+type LockOptions struct {
+	// Timeout limits the time spent waiting for the lock held by another process.
+	Timeout time.Duration
+	// RetryInterval is a pause between attempts to acquire the lock.
+	RetryInterval time.Duration
+	// TTL is the age after which a lock row is considered stale (its owner crashed) and is taken over.
+	// The owner refreshes its row while it holds the lock, so TTL doesn't limit the duration of a run.
+	// Only used by the lock table, session-level advisory locks are released by the database itself.
+	TTL time.Duration
+}
+
+// advisoryLockBuilder is implemented by query builders of databases with session-level advisory locks.
+// Queries must return a single boolean column, true if the lock was acquired (released).
+type advisoryLockBuilder interface {
+	TryAdvisoryLock(tableName string) string
+	AdvisoryUnlock(tableName string) string
+}
+
+// lockTableBuilder is implemented by query builders of databases without advisory locks.
+type lockTableBuilder interface {
+	CreateLockTable(lockTableName string) string
+	InsertLock(lockTableName string) string
+	SelectLockOwner(lockTableName string) string
+	RefreshLock(lockTableName string) string
+	DeleteLock(lockTableName string) string
+	DeleteStaleLocks(lockTableName string) string
+}
+
+// NewLocker returns a Locker for the migrations table. Advisory locks are used if the dialect supports them,
+// otherwise the lock is a row in a separate lock table, which is created if not exists.
+func (s Store) NewLocker(db *sql.DB, opts LockOptions) (Locker, error) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultLockTimeout
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = DefaultLockTTL
+	}
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = DefaultLockRetryInterval
+	}
+
+	switch b := s.queryManager.(type) {
+	case advisoryLockBuilder:
+		return &advisoryLocker{
+			db:       db,
+			opts:     opts,
+			tryLockQ: b.TryAdvisoryLock(s.tableName),
+			unlockQ:  b.AdvisoryUnlock(s.tableName),
+		}, nil
+	case lockTableBuilder:
+		return &tableLocker{
+			db:        db,
+			opts:      opts,
+			builder:   b,
+			tableName: s.tableName + lockTableSuffix,
+			owner:     newLockOwner(),
+		}, nil
+	default:
+		return nil, ErrLockUnsupported
+	}
+}
+
+// advisoryLocker holds a session-level advisory lock on a dedicated connection,
+// the lock is released by the database if the connection is lost.
+type advisoryLocker struct {
+	db       *sql.DB
+	opts     LockOptions
+	tryLockQ string
+	unlockQ  string
+
+	conn *sql.Conn
+}
+
+func (l *advisoryLocker) Lock(ctx context.Context) error {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection for advisory lock: %w", err)
+	}
+
+	err = retry(ctx, l.opts, func() (bool, error) {
+		var locked bool
+		if err := conn.QueryRowContext(ctx, l.tryLockQ).Scan(&locked); err != nil {
+			return false, fmt.Errorf("failed to try advisory lock: %w", err)
+		}
+		return locked, nil
+	})
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+
+	l.conn = conn
+	return nil
+}
+
+func (l *advisoryLocker) Unlock(ctx context.Context) error {
+	if l.conn == nil {
+		return ErrLockNotHeld
+	}
+	defer func() {
+		_ = l.conn.Close()
+		l.conn = nil
+	}()
+
+	var unlocked bool
+	if err := l.conn.QueryRowContext(ctx, l.unlockQ).Scan(&unlocked); err != nil {
+		return fmt.Errorf("failed to release advisory lock: %w", err)
+	}
+	if !unlocked {
+		return ErrLockNotHeld
+	}
+
+	return nil
+}
+
+// tableLocker keeps the lock as a row in the lock table. It doesn't rely on unique constraints,
+// so it works with databases without them (e.g. ClickHouse): every contender inserts its own row,
+// and the lock belongs to the oldest one. Rows older than TTL are considered stale and removed,
+// so the owner refreshes its row in the background until the lock is released.
+type tableLocker struct {
+	db        *sql.DB
+	opts      LockOptions
+	builder   lockTableBuilder
+	tableName string
+	owner     string
+
+	stopRefresh context.CancelFunc
+	refreshDone chan struct{}
+}
+
+func (l *tableLocker) Lock(ctx context.Context) error {
+	if _, err := l.db.ExecContext(ctx, l.builder.CreateLockTable(l.tableName)); err != nil {
+		return fmt.Errorf("failed to create lock table: %w", err)
+	}
+
+	err := retry(ctx, l.opts, func() (bool, error) {
+		return l.tryLock(ctx)
+	})
+	if err != nil {
+		return err
+	}
+
+	l.startRefresh(ctx)
+	return nil
+}
+
+func (l *tableLocker) Unlock(ctx context.Context) error {
+	if l.stopRefresh == nil {
+		return ErrLockNotHeld
+	}
+	l.stopRefresh()
+	<-l.refreshDone
+	l.stopRefresh = nil
+
+	owner, err := l.currentOwner(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = l.deleteOwn(ctx); err != nil {
+		return err
+	}
+
+	if owner != l.owner {
+		return ErrLockNotFound
+	}
+
+	return nil
+}
+
+func (l *tableLocker) tryLock(ctx context.Context) (bool, error) {
+	staleBefore := time.Now().UTC().Add(-l.opts.TTL)
+	if _, err := l.db.ExecContext(ctx, l.builder.DeleteStaleLocks(l.tableName), staleBefore); err != nil {
+		return false, fmt.Errorf("failed to delete stale locks: %w", err)
+	}
+
+	owner, err := l.currentOwner(ctx)
+	if err != nil {
+		return false, err
+	}
+	if owner != "" {
+		return owner == l.owner, nil
+	}
+
+	// Some drivers (e.g. ClickHouse) support inserts in a transaction only.
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, l.builder.InsertLock(l.tableName), lockRowID, l.owner, time.Now().UTC())
+	if err != nil {
+		_ = tx.Rollback()
+		// Another process may have inserted its row first, the winner is checked on the next attempt.
+		return false, nil //nolint:nilerr
+	}
+	if err = tx.Commit(); err != nil {
+		return false, nil //nolint:nilerr
+	}
+
+	owner, err = l.currentOwner(ctx)
+	if err != nil {
+		return false, err
+	}
+	if owner == l.owner {
+		return true, nil
+	}
+
+	// Our row was inserted concurrently with another one, which won.
+	return false, l.deleteOwn(ctx)
+}
+
+// startRefresh refreshes the lock row several times within TTL until Unlock, so that the lock
+// isn't taken over as stale while a long run holds it. A failed refresh is retried on the next tick,
+// if the lock is taken over meanwhile, Unlock returns ErrLockNotFound.
+func (l *tableLocker) startRefresh(ctx context.Context) {
+	// The lock is released with a context that is not canceled, so it's refreshed until then.
+	ctx, l.stopRefresh = context.WithCancel(context.WithoutCancel(ctx))
+	l.refreshDone = make(chan struct{})
+
+	go func() {
+		defer close(l.refreshDone)
+		ticker := time.NewTicker(l.opts.TTL / lockRefreshesPerTTL)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				_, _ = l.db.ExecContext(ctx, l.builder.RefreshLock(l.tableName), time.Now().UTC(), l.owner)
+			}
+		}
+	}()
+}
+
+// currentOwner returns the owner of the oldest lock row or an empty string if there is no lock.
+func (l *tableLocker) currentOwner(ctx context.Context) (string, error) {
+	var owner string
+	err := l.db.QueryRowContext(ctx, l.builder.SelectLockOwner(l.tableName)).Scan(&owner)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to select lock owner: %w", err)
+	}
+	return owner, nil
+}
+
+func (l *tableLocker) deleteOwn(ctx context.Context) error {
+	if _, err := l.db.ExecContext(ctx, l.builder.DeleteLock(l.tableName), l.owner); err != nil {
+		return fmt.Errorf("failed to delete lock: %w", err)
+	}
+	return nil
+}
+
+// retry calls try until it acquires the lock, returns an error, or the timeout expires.
+func retry(ctx context.Context, opts LockOptions, try func() (bool, error)) error {
+	deadline := time.Now().Add(opts.Timeout)
+	for {
+		acquired, err := try()
+		if err != nil {
+			return err
+		}
+		if acquired {
+			return nil
+		}
+
+		if time.Now().Add(opts.RetryInterval).After(deadline) {
+			return ErrLockTimeout
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(opts.RetryInterval):
+		}
+	}
+}
+
+// advisoryLockKey derives a numeric advisory lock key from the qualified migrations table name,
+// so that different migrations tables in one database don't block each other.
+func advisoryLockKey(qualifiedTableName string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte("migratory:" + qualifiedTableName))
+	return int64(h.Sum64()) //nolint:gosec
+}
+
+func newLockOwner() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,105 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/evgodev/migratory/internal/require"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestRetry(t *testing.T) {
+	t.Parallel()
+	errTry := errors.New("try error")
+	opts := LockOptions{Timeout: 50 * time.Millisecond, RetryInterval: time.Millisecond}
+
+	tests := map[string]struct {
+		acquireAfter int
+		tryErr       error
+		wantErr      error
+	}{
+		"acquired at once":       {acquireAfter: 1, wantErr: nil},
+		"acquired after retries": {acquireAfter: 5, wantErr: nil},
+		"never acquired":         {acquireAfter: -1, wantErr: ErrLockTimeout},
+		"try error":              {acquireAfter: 1, tryErr: errTry, wantErr: errTry},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var attempts int
+			err := retry(context.Background(), opts, func() (bool, error) {
+				attempts++
+				return attempts == test.acquireAfter, test.tryErr
+			})
+			require.ErrorIs(t, err, test.wantErr, "retry(...) error")
+		})
+	}
+}
+
+func TestRetryContextCanceled(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	opts := LockOptions{Timeout: time.Minute, RetryInterval: time.Second}
+	err := retry(ctx, opts, func() (bool, error) { return false, nil })
+	require.ErrorIs(t, err, context.Canceled, "retry(...) error")
+}
+
+func TestNewLocker(t *testing.T) {
+	t.Parallel()
+	postgres, err := New(Postgres, "migrations")
+	require.NoError(t, err, "New(...) postgres store")
+	locker, err := postgres.NewLocker(nil, LockOptions{})
+	require.NoError(t, err, "NewLocker(...) postgres error")
+	if _, ok := locker.(*advisoryLocker); !ok {
+		t.Fatalf("NewLocker(...) postgres: got %T, want *advisoryLocker", locker)
+	}
+
+	clickhouse, err := New(ClickHouse, "migrations")
+	require.NoError(t, err, "New(...) clickhouse store")
+	locker, err = clickhouse.NewLocker(nil, LockOptions{})
+	require.NoError(t, err, "NewLocker(...) clickhouse error")
+	tl, ok := locker.(*tableLocker)
+	if !ok {
+		t.Fatalf("NewLocker(...) clickhouse: got %T, want *tableLocker", locker)
+	}
+	require.String(t, tl.tableName, "migrations_lock", "NewLocker(...) lock table name")
+	require.Equal(t, tl.opts.TTL, DefaultLockTTL, "NewLocker(...) default TTL")
+}
+
+func TestTableLockerRefresh(t *testing.T) {
+	t.Parallel()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "lock.db")+"?_pragma=busy_timeout(5000)")
+	require.NoError(t, err, "sql.Open(...) error")
+	t.Cleanup(func() {
+		require.NoError(t, db.Close(), "db.Close()")
+	})
+
+	sqlite, err := New(SQLite, "migrations")
+	require.NoError(t, err, "New(...) sqlite store")
+	opts := LockOptions{Timeout: 100 * time.Millisecond, RetryInterval: 10 * time.Millisecond, TTL: 300 * time.Millisecond}
+	holder, err := sqlite.NewLocker(db, opts)
+	require.NoError(t, err, "NewLocker(...) holder error")
+	contender, err := sqlite.NewLocker(db, opts)
+	require.NoError(t, err, "NewLocker(...) contender error")
+
+	ctx := context.Background()
+	require.NoError(t, holder.Lock(ctx), "holder.Lock(...) error")
+
+	// The lock is held for longer than TTL, it must not be taken over as stale.
+	time.Sleep(3 * opts.TTL)
+	err = contender.Lock(ctx)
+	require.ErrorIs(t, err, ErrLockTimeout, "contender.Lock(...) error while the lock is refreshed")
+
+	require.NoError(t, holder.Unlock(ctx), "holder.Unlock(...) error")
+	require.ErrorIs(t, holder.Unlock(ctx), ErrLockNotHeld, "holder.Unlock(...) second call error")
+	require.NoError(t, contender.Lock(ctx), "contender.Lock(...) error after unlock")
+	require.NoError(t, contender.Unlock(ctx), "contender.Unlock(...) error")
+}
@@ -0,0 +1,146 @@
+package store
+
+import "fmt"
+
+type mysqlQueryBuilder struct{}
+
+func (m *mysqlQueryBuilder) MigrationsTableExists(tableName string) string {
+	q := `SELECT EXISTS (SELECT 1 FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = '%s')`
+	return fmt.Sprintf(q, tableName)
+}
+
+func (m *mysqlQueryBuilder) CreateMigrationsTable(tableName string) string {
+	q := `CREATE TABLE IF NOT EXISTS %s (
+		id BIGINT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMP NOT NULL,
+		checksum VARCHAR(64) NOT NULL DEFAULT '',
+		baselined BOOLEAN NOT NULL DEFAULT FALSE
+	)`
+	return fmt.Sprintf(q, tableName)
+}
+
+func (m *mysqlQueryBuilder) InsertMigration(tableName string) string {
+	q := `INSERT INTO %s (id, name, applied_at, checksum) VALUES (?, ?, now(), ?)`
+	return fmt.Sprintf(q, tableName)
+}
+
+func (m *mysqlQueryBuilder) InsertBaselinedMigration(tableName string) string {
+	q := `INSERT INTO %s (id, name, applied_at, checksum, baselined) VALUES (?, ?, now(), ?, TRUE)`
+	return fmt.Sprintf(q, tableName)
+}
+
+func (m *mysqlQueryBuilder) InsertImportedMigration(tableName string) string {
+	q := `INSERT INTO %s (id, name, applied_at, checksum) VALUES (?, ?, ?, ?)`
+	return fmt.Sprintf(q, tableName)
+}
+
+func (m *mysqlQueryBuilder) DeleteMigration(tableName string) string {
+	q := `DELETE FROM %s WHERE id = ?`
+	return fmt.Sprintf(q, tableName)
+}
+
+func (m *mysqlQueryBuilder) ListMigrations(tableName string) string {
+	q := `SELECT id, name, applied_at, checksum, baselined FROM %s ORDER BY id ASC`
+	return fmt.Sprintf(q, tableName)
+}
+
+func (m *mysqlQueryBuilder) ListLegacyMigrations(tableName string) string {
+	q := `SELECT id, name, applied_at FROM %s ORDER BY id ASC`
+	return fmt.Sprintf(q, tableName)
+}
+
+func (m *mysqlQueryBuilder) SelectColumns(tableName string) string {
+	q := `SELECT * FROM %s LIMIT 0`
+	return fmt.Sprintf(q, tableName)
+}
+
+func (m *mysqlQueryBuilder) AddChecksumColumn(tableName string) string {
+	q := `ALTER TABLE %s ADD COLUMN checksum VARCHAR(64) NOT NULL DEFAULT ''`
+	return fmt.Sprintf(q, tableName)
+}
+
+func (m *mysqlQueryBuilder) AddBaselinedColumn(tableName string) string {
+	q := `ALTER TABLE %s ADD COLUMN baselined BOOLEAN NOT NULL DEFAULT FALSE`
+	return fmt.Sprintf(q, tableName)
+}
+
+func (m *mysqlQueryBuilder) SelectLastMigrationID(tableName string) string {
+	q := `SELECT id FROM %s ORDER BY id DESC LIMIT 1`
+	return fmt.Sprintf(q, tableName)
+}
+
+// TryAdvisoryLock uses a named lock; GET_LOCK returns NULL on error, which is treated as not acquired.
+// Lock names are limited to 64 characters, so the name is derived from a hash of the table name.
+func (m *mysqlQueryBuilder) TryAdvisoryLock(tableName string) string {
+	q := `SELECT COALESCE(GET_LOCK('migratory_%x', 0), 0)`
+	return fmt.Sprintf(q, uint64(advisoryLockKey(tableName)))
+}
+
+func (m *mysqlQueryBuilder) AdvisoryUnlock(tableName string) string {
+	q := `SELECT COALESCE(RELEASE_LOCK('migratory_%x'), 0)`
+	return fmt.Sprintf(q, uint64(advisoryLockKey(tableName)))
+}
+
+// CommitsDDL reports that MySQL implicitly commits the current transaction on DDL statements.
+func (m *mysqlQueryBuilder) CommitsDDL() bool {
+	return true
+}
+
+// BackslashEscapes reports that a backslash escapes the next character in MySQL string literals.
+func (m *mysqlQueryBuilder) BackslashEscapes() bool {
+	return true
+}
+
+func (m *mysqlQueryBuilder) CreateDirtyTable(dirtyTableName string) string {
+	q := `CREATE TABLE IF NOT EXISTS %s (
+		id BIGINT NOT NULL,
+		name VARCHAR(255) NOT NULL,
+		direction VARCHAR(4) NOT NULL,
+		statement BIGINT NOT NULL,
+		checksum VARCHAR(64) NOT NULL,
+		created_at TIMESTAMP NOT NULL
+	)`
+	return fmt.Sprintf(q, dirtyTableName)
+}
+
+func (m *mysqlQueryBuilder) InsertDirtyMark(dirtyTableName string) string {
+	q := `INSERT INTO %s (id, name, direction, statement, checksum, created_at)
+		VALUES (?, ?, ?, ?, ?, now())`
+	return fmt.Sprintf(q, dirtyTableName)
+}
+
+func (m *mysqlQueryBuilder) SelectDirtyMark(dirtyTableName string) string {
+	q := `SELECT id, name, direction, statement, checksum, created_at FROM %s
+		ORDER BY statement DESC, created_at DESC LIMIT 1`
+	return fmt.Sprintf(q, dirtyTableName)
+}
+
+func (m *mysqlQueryBuilder) DeleteDirtyMarks(dirtyTableName string) string {
+	q := `DELETE FROM %s WHERE id = ?`
+	return fmt.Sprintf(q, dirtyTableName)
+}
+
+func (m *mysqlQueryBuilder) CreateRepeatableTable(repeatableTableName string) string {
+	q := `CREATE TABLE IF NOT EXISTS %s (
+		name VARCHAR(255) NOT NULL,
+		checksum VARCHAR(64) NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`
+	return fmt.Sprintf(q, repeatableTableName)
+}
+
+func (m *mysqlQueryBuilder) InsertRepeatable(repeatableTableName string) string {
+	q := `INSERT INTO %s (name, checksum, applied_at) VALUES (?, ?, now())`
+	return fmt.Sprintf(q, repeatableTableName)
+}
+
+func (m *mysqlQueryBuilder) DeleteRepeatable(repeatableTableName string) string {
+	q := `DELETE FROM %s WHERE name = ?`
+	return fmt.Sprintf(q, repeatableTableName)
+}
+
+func (m *mysqlQueryBuilder) ListRepeatables(repeatableTableName string) string {
+	q := `SELECT name, checksum, applied_at FROM %s ORDER BY name ASC`
+	return fmt.Sprintf(q, repeatableTableName)
+}
@@ -2,40 +2,142 @@ package store
 
 import "fmt"
 
-const schemaName = "public"
+const defaultSchemaName = "public"
 
-type postgresQueryBuilder struct{}
+type postgresQueryBuilder struct {
+	schema string
+}
+
+func (p *postgresQueryBuilder) CreateSchema() string {
+	q := `CREATE SCHEMA IF NOT EXISTS %s`
+	return fmt.Sprintf(q, p.schema)
+}
 
 func (p *postgresQueryBuilder) MigrationsTableExists(tableName string) string {
 	q := `SELECT EXISTS (SELECT FROM pg_tables WHERE schemaname = '%s' AND tablename  = '%s')`
-	return fmt.Sprintf(q, schemaName, tableName)
+	return fmt.Sprintf(q, p.schema, tableName)
 }
 
 func (p *postgresQueryBuilder) CreateMigrationsTable(tableName string) string {
-	q := `CREATE TABLE %s.%s (
+	q := `CREATE TABLE IF NOT EXISTS %s.%s (
 		id bigint PRIMARY KEY,
 		name VARCHAR(255) NOT NULL,
-		applied_at timestamp NOT NULL
+		applied_at timestamp NOT NULL,
+		checksum VARCHAR(64) NOT NULL DEFAULT '',
+		baselined BOOLEAN NOT NULL DEFAULT FALSE
 	)`
-	return fmt.Sprintf(q, schemaName, tableName)
+	return fmt.Sprintf(q, p.schema, tableName)
 }
 
 func (p *postgresQueryBuilder) InsertMigration(tableName string) string {
-	q := `INSERT INTO %s.%s (id, name, applied_at) VALUES ($1, $2, now())`
-	return fmt.Sprintf(q, schemaName, tableName)
+	q := `INSERT INTO %s.%s (id, name, applied_at, checksum) VALUES ($1, $2, now(), $3)`
+	return fmt.Sprintf(q, p.schema, tableName)
+}
+
+func (p *postgresQueryBuilder) InsertBaselinedMigration(tableName string) string {
+	q := `INSERT INTO %s.%s (id, name, applied_at, checksum, baselined) VALUES ($1, $2, now(), $3, TRUE)`
+	return fmt.Sprintf(q, p.schema, tableName)
+}
+
+func (p *postgresQueryBuilder) InsertImportedMigration(tableName string) string {
+	q := `INSERT INTO %s.%s (id, name, applied_at, checksum) VALUES ($1, $2, $3, $4)`
+	return fmt.Sprintf(q, p.schema, tableName)
 }
 
 func (p *postgresQueryBuilder) DeleteMigration(tableName string) string {
 	q := `DELETE FROM %s.%s WHERE id = $1`
-	return fmt.Sprintf(q, schemaName, tableName)
+	return fmt.Sprintf(q, p.schema, tableName)
 }
 
 func (p *postgresQueryBuilder) ListMigrations(tableName string) string {
+	q := `SELECT id, name, applied_at, checksum, baselined FROM %s.%s ORDER BY id ASC`
+	return fmt.Sprintf(q, p.schema, tableName)
+}
+
+func (p *postgresQueryBuilder) ListLegacyMigrations(tableName string) string {
 	q := `SELECT id, name, applied_at FROM %s.%s ORDER BY id ASC`
-	return fmt.Sprintf(q, schemaName, tableName)
+	return fmt.Sprintf(q, p.schema, tableName)
+}
+
+func (p *postgresQueryBuilder) SelectColumns(tableName string) string {
+	q := `SELECT * FROM %s.%s LIMIT 0`
+	return fmt.Sprintf(q, p.schema, tableName)
+}
+
+func (p *postgresQueryBuilder) AddChecksumColumn(tableName string) string {
+	q := `ALTER TABLE %s.%s ADD COLUMN checksum VARCHAR(64) NOT NULL DEFAULT ''`
+	return fmt.Sprintf(q, p.schema, tableName)
+}
+
+func (p *postgresQueryBuilder) AddBaselinedColumn(tableName string) string {
+	q := `ALTER TABLE %s.%s ADD COLUMN baselined BOOLEAN NOT NULL DEFAULT FALSE`
+	return fmt.Sprintf(q, p.schema, tableName)
 }
 
 func (p *postgresQueryBuilder) SelectLastMigrationID(tableName string) string {
 	q := `SELECT id FROM %s.%s ORDER BY id DESC LIMIT 1`
-	return fmt.Sprintf(q, schemaName, tableName)
+	return fmt.Sprintf(q, p.schema, tableName)
+}
+
+func (p *postgresQueryBuilder) TryAdvisoryLock(tableName string) string {
+	q := `SELECT pg_try_advisory_lock(%d)`
+	return fmt.Sprintf(q, advisoryLockKey(p.schema+"."+tableName))
+}
+
+func (p *postgresQueryBuilder) AdvisoryUnlock(tableName string) string {
+	q := `SELECT pg_advisory_unlock(%d)`
+	return fmt.Sprintf(q, advisoryLockKey(p.schema+"."+tableName))
+}
+
+func (p *postgresQueryBuilder) CreateDirtyTable(dirtyTableName string) string {
+	q := `CREATE TABLE IF NOT EXISTS %s.%s (
+		id bigint NOT NULL,
+		name VARCHAR(255) NOT NULL,
+		direction VARCHAR(4) NOT NULL,
+		statement bigint NOT NULL,
+		checksum VARCHAR(64) NOT NULL,
+		created_at timestamp NOT NULL
+	)`
+	return fmt.Sprintf(q, p.schema, dirtyTableName)
+}
+
+func (p *postgresQueryBuilder) InsertDirtyMark(dirtyTableName string) string {
+	q := `INSERT INTO %s.%s (id, name, direction, statement, checksum, created_at)
+		VALUES ($1, $2, $3, $4, $5, now())`
+	return fmt.Sprintf(q, p.schema, dirtyTableName)
+}
+
+func (p *postgresQueryBuilder) SelectDirtyMark(dirtyTableName string) string {
+	q := `SELECT id, name, direction, statement, checksum, created_at FROM %s.%s
+		ORDER BY statement DESC, created_at DESC LIMIT 1`
+	return fmt.Sprintf(q, p.schema, dirtyTableName)
+}
+
+func (p *postgresQueryBuilder) DeleteDirtyMarks(dirtyTableName string) string {
+	q := `DELETE FROM %s.%s WHERE id = $1`
+	return fmt.Sprintf(q, p.schema, dirtyTableName)
+}
+
+func (p *postgresQueryBuilder) CreateRepeatableTable(repeatableTableName string) string {
+	q := `CREATE TABLE IF NOT EXISTS %s.%s (
+		name VARCHAR(255) NOT NULL,
+		checksum VARCHAR(64) NOT NULL,
+		applied_at timestamp NOT NULL
+	)`
+	return fmt.Sprintf(q, p.schema, repeatableTableName)
+}
+
+func (p *postgresQueryBuilder) InsertRepeatable(repeatableTableName string) string {
+	q := `INSERT INTO %s.%s (name, checksum, applied_at) VALUES ($1, $2, now())`
+	return fmt.Sprintf(q, p.schema, repeatableTableName)
+}
+
+func (p *postgresQueryBuilder) DeleteRepeatable(repeatableTableName string) string {
+	q := `DELETE FROM %s.%s WHERE name = $1`
+	return fmt.Sprintf(q, p.schema, repeatableTableName)
+}
+
+func (p *postgresQueryBuilder) ListRepeatables(repeatableTableName string) string {
+	q := `SELECT name, checksum, applied_at FROM %s.%s ORDER BY name ASC`
+	return fmt.Sprintf(q, p.schema, repeatableTableName)
 }
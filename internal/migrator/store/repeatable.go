@@ -0,0 +1,63 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const repeatableTableSuffix = "_repeatable"
+
+// RepeatableResult is a record of the last run of a repeatable migration.
+type RepeatableResult struct {
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+func (s Store) repeatableTableName() string {
+	return s.tableName + repeatableTableSuffix
+}
+
+// CreateRepeatableTable creates the table of repeatable migration runs if not exists.
+func (s Store) CreateRepeatableTable(ctx context.Context, db database) error {
+	_, err := db.ExecContext(ctx, s.queryManager.CreateRepeatableTable(s.repeatableTableName()))
+	return err
+}
+
+// InsertRepeatable records the run of the repeatable migration with its checksum,
+// replacing the record of the previous run.
+func (s Store) InsertRepeatable(ctx context.Context, db database, name, checksum string) error {
+	if _, err := db.ExecContext(ctx, s.queryManager.DeleteRepeatable(s.repeatableTableName()), name); err != nil {
+		return fmt.Errorf("failed to delete previous run: %w", err)
+	}
+
+	_, err := db.ExecContext(ctx, s.queryManager.InsertRepeatable(s.repeatableTableName()), name, checksum)
+	return err
+}
+
+// ListRepeatables returns the records of the last runs of repeatable migrations ordered by name.
+func (s Store) ListRepeatables(ctx context.Context, db database) ([]RepeatableResult, error) {
+	if s.absent != nil && s.absent.repeatable {
+		return nil, nil
+	}
+
+	rows, err := db.QueryContext(ctx, s.queryManager.ListRepeatables(s.repeatableTableName()))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var results []RepeatableResult
+	for rows.Next() {
+		var r RepeatableResult
+		if err = rows.Scan(&r.Name, &r.Checksum, &r.AppliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan repeatable migration: %w", err)
+		}
+		results = append(results, r)
+	}
+
+	return results, rows.Err()
+}
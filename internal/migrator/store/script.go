@@ -0,0 +1,91 @@
+package store
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// transactionless is implemented by query builders of databases without SQL transactions,
+// their migrations are written to SQL scripts without BEGIN and COMMIT.
+type transactionless interface {
+	Transactionless() bool
+}
+
+// backslashEscaper is implemented by query builders of databases where a backslash escapes the next character
+// in string literals, so it must be escaped itself in literals of SQL scripts.
+type backslashEscaper interface {
+	BackslashEscapes() bool
+}
+
+// SupportsTransactions reports whether migrations in SQL scripts can be wrapped in BEGIN and COMMIT.
+func (s Store) SupportsTransactions() bool {
+	t, ok := s.queryManager.(transactionless)
+	return !ok || !t.Transactionless()
+}
+
+// CreateMigrationsTableScript returns the queries creating the migrations table (and its schema) if not exists,
+// to be run in a SQL script.
+func (s Store) CreateMigrationsTableScript() string {
+	var script string
+	if c, ok := s.queryManager.(schemaCreator); ok {
+		script = s.bindLiterals(c.CreateSchema()) + "\n"
+	}
+	return script + s.bindLiterals(s.queryManager.CreateMigrationsTable(s.tableName))
+}
+
+// InsertMigrationScript returns the InsertMigration query with literal values, to be run in a SQL script.
+func (s Store) InsertMigrationScript(migrationName string, id int64, checksum string) string {
+	return s.bindLiterals(s.queryManager.InsertMigration(s.tableName), id, migrationName, checksum)
+}
+
+// DeleteMigrationScript returns the DeleteMigration query with literal values, to be run in a SQL script.
+func (s Store) DeleteMigrationScript(id int64) string {
+	return s.bindLiterals(s.queryManager.DeleteMigration(s.tableName), id)
+}
+
+// bindLiterals replaces placeholders of the query (? or $1, $2, ...) with literals of args in order
+// and terminates the query with a semicolon. Queries of the query builders have no placeholder-like text
+// in their string literals, so placeholders are simply searched for.
+func (s Store) bindLiterals(query string, args ...any) string {
+	e, ok := s.queryManager.(backslashEscaper)
+	backslashEscapes := ok && e.BackslashEscapes()
+
+	var b strings.Builder
+	next := 0
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '?':
+		case c == '$' && i+1 < len(query) && query[i+1] >= '0' && query[i+1] <= '9':
+			for i+1 < len(query) && query[i+1] >= '0' && query[i+1] <= '9' {
+				i++
+			}
+		default:
+			b.WriteByte(c)
+			continue
+		}
+
+		if next < len(args) {
+			b.WriteString(literal(args[next], backslashEscapes))
+		}
+		next++
+	}
+
+	return strings.TrimSuffix(strings.TrimSpace(b.String()), ";") + ";"
+}
+
+// literal returns the SQL literal of arg, backslashes in strings are doubled if backslashEscapes is set.
+func literal(arg any, backslashEscapes bool) string {
+	switch v := arg.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case string:
+		if backslashEscapes {
+			v = strings.ReplaceAll(v, `\`, `\\`)
+		}
+		return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	default:
+		return fmt.Sprintf("'%v'", v)
+	}
+}
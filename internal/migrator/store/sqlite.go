@@ -0,0 +1,169 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+type sqliteQueryBuilder struct{}
+
+func (s *sqliteQueryBuilder) MigrationsTableExists(tableName string) string {
+	q := `SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = '%s')`
+	return fmt.Sprintf(q, tableName)
+}
+
+func (s *sqliteQueryBuilder) CreateMigrationsTable(tableName string) string {
+	q := `CREATE TABLE IF NOT EXISTS %s (
+		id INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL,
+		checksum TEXT NOT NULL DEFAULT '',
+		baselined BOOLEAN NOT NULL DEFAULT 0
+	)`
+	return fmt.Sprintf(q, tableName)
+}
+
+// InsertMigration stores applied_at with milliseconds, CURRENT_TIMESTAMP has a precision of seconds only.
+func (s *sqliteQueryBuilder) InsertMigration(tableName string) string {
+	q := `INSERT INTO %s (id, name, applied_at, checksum) VALUES (?, ?, strftime('%%Y-%%m-%%d %%H:%%M:%%f', 'now'), ?)`
+	return fmt.Sprintf(q, tableName)
+}
+
+func (s *sqliteQueryBuilder) InsertBaselinedMigration(tableName string) string {
+	q := `INSERT INTO %s (id, name, applied_at, checksum, baselined)
+		VALUES (?, ?, strftime('%%Y-%%m-%%d %%H:%%M:%%f', 'now'), ?, 1)`
+	return fmt.Sprintf(q, tableName)
+}
+
+func (s *sqliteQueryBuilder) InsertImportedMigration(tableName string) string {
+	q := `INSERT INTO %s (id, name, applied_at, checksum) VALUES (?, ?, ?, ?)`
+	return fmt.Sprintf(q, tableName)
+}
+
+// FormatTime formats the time like InsertMigration stores applied_at, in UTC with milliseconds.
+func (s *sqliteQueryBuilder) FormatTime(t time.Time) any {
+	return t.UTC().Format("2006-01-02 15:04:05.000")
+}
+
+func (s *sqliteQueryBuilder) DeleteMigration(tableName string) string {
+	q := `DELETE FROM %s WHERE id = ?`
+	return fmt.Sprintf(q, tableName)
+}
+
+func (s *sqliteQueryBuilder) ListMigrations(tableName string) string {
+	q := `SELECT id, name, applied_at, checksum, baselined FROM %s ORDER BY id ASC`
+	return fmt.Sprintf(q, tableName)
+}
+
+func (s *sqliteQueryBuilder) ListLegacyMigrations(tableName string) string {
+	q := `SELECT id, name, applied_at FROM %s ORDER BY id ASC`
+	return fmt.Sprintf(q, tableName)
+}
+
+func (s *sqliteQueryBuilder) SelectColumns(tableName string) string {
+	q := `SELECT * FROM %s LIMIT 0`
+	return fmt.Sprintf(q, tableName)
+}
+
+func (s *sqliteQueryBuilder) AddChecksumColumn(tableName string) string {
+	q := `ALTER TABLE %s ADD COLUMN checksum TEXT NOT NULL DEFAULT ''`
+	return fmt.Sprintf(q, tableName)
+}
+
+func (s *sqliteQueryBuilder) AddBaselinedColumn(tableName string) string {
+	q := `ALTER TABLE %s ADD COLUMN baselined BOOLEAN NOT NULL DEFAULT 0`
+	return fmt.Sprintf(q, tableName)
+}
+
+func (s *sqliteQueryBuilder) SelectLastMigrationID(tableName string) string {
+	q := `SELECT id FROM %s ORDER BY id DESC LIMIT 1`
+	return fmt.Sprintf(q, tableName)
+}
+
+// CreateLockTable creates the lock table with a single possible row, so only one process can insert it.
+func (s *sqliteQueryBuilder) CreateLockTable(lockTableName string) string {
+	q := `CREATE TABLE IF NOT EXISTS %s (
+		id INTEGER PRIMARY KEY,
+		owner TEXT NOT NULL,
+		locked_at TIMESTAMP NOT NULL
+	)`
+	return fmt.Sprintf(q, lockTableName)
+}
+
+func (s *sqliteQueryBuilder) InsertLock(lockTableName string) string {
+	q := `INSERT INTO %s (id, owner, locked_at) VALUES (?, ?, ?)`
+	return fmt.Sprintf(q, lockTableName)
+}
+
+func (s *sqliteQueryBuilder) SelectLockOwner(lockTableName string) string {
+	q := `SELECT owner FROM %s ORDER BY locked_at ASC, owner ASC LIMIT 1`
+	return fmt.Sprintf(q, lockTableName)
+}
+
+func (s *sqliteQueryBuilder) RefreshLock(lockTableName string) string {
+	q := `UPDATE %s SET locked_at = ? WHERE owner = ?`
+	return fmt.Sprintf(q, lockTableName)
+}
+
+func (s *sqliteQueryBuilder) DeleteLock(lockTableName string) string {
+	q := `DELETE FROM %s WHERE owner = ?`
+	return fmt.Sprintf(q, lockTableName)
+}
+
+func (s *sqliteQueryBuilder) DeleteStaleLocks(lockTableName string) string {
+	q := `DELETE FROM %s WHERE locked_at < ?`
+	return fmt.Sprintf(q, lockTableName)
+}
+
+func (s *sqliteQueryBuilder) CreateDirtyTable(dirtyTableName string) string {
+	q := `CREATE TABLE IF NOT EXISTS %s (
+		id INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		direction TEXT NOT NULL,
+		statement INTEGER NOT NULL,
+		checksum TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL
+	)`
+	return fmt.Sprintf(q, dirtyTableName)
+}
+
+func (s *sqliteQueryBuilder) InsertDirtyMark(dirtyTableName string) string {
+	q := `INSERT INTO %s (id, name, direction, statement, checksum, created_at)
+		VALUES (?, ?, ?, ?, ?, strftime('%%Y-%%m-%%d %%H:%%M:%%f', 'now'))`
+	return fmt.Sprintf(q, dirtyTableName)
+}
+
+func (s *sqliteQueryBuilder) SelectDirtyMark(dirtyTableName string) string {
+	q := `SELECT id, name, direction, statement, checksum, created_at FROM %s
+		ORDER BY statement DESC, created_at DESC LIMIT 1`
+	return fmt.Sprintf(q, dirtyTableName)
+}
+
+func (s *sqliteQueryBuilder) DeleteDirtyMarks(dirtyTableName string) string {
+	q := `DELETE FROM %s WHERE id = ?`
+	return fmt.Sprintf(q, dirtyTableName)
+}
+
+func (s *sqliteQueryBuilder) CreateRepeatableTable(repeatableTableName string) string {
+	q := `CREATE TABLE IF NOT EXISTS %s (
+		name TEXT NOT NULL,
+		checksum TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`
+	return fmt.Sprintf(q, repeatableTableName)
+}
+
+func (s *sqliteQueryBuilder) InsertRepeatable(repeatableTableName string) string {
+	q := `INSERT INTO %s (name, checksum, applied_at) VALUES (?, ?, strftime('%%Y-%%m-%%d %%H:%%M:%%f', 'now'))`
+	return fmt.Sprintf(q, repeatableTableName)
+}
+
+func (s *sqliteQueryBuilder) DeleteRepeatable(repeatableTableName string) string {
+	q := `DELETE FROM %s WHERE name = ?`
+	return fmt.Sprintf(q, repeatableTableName)
+}
+
+func (s *sqliteQueryBuilder) ListRepeatables(repeatableTableName string) string {
+	q := `SELECT name, checksum, applied_at FROM %s ORDER BY name ASC`
+	return fmt.Sprintf(q, repeatableTableName)
+}
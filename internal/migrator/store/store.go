@@ -12,12 +12,15 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 )
 
 const (
 	Postgres   Dialect = "postgres"
 	ClickHouse Dialect = "clickhouse"
+	MySQL      Dialect = "mysql"
+	SQLite     Dialect = "sqlite"
 )
 
 var (
@@ -28,6 +31,16 @@ var (
 type Store struct {
 	tableName    string
 	queryManager queryBuilder
+	absent       *absence // Set by Inspect, nil if the tables are created and upgraded.
+}
+
+// absence records which tables of the store don't exist and whether the migrations table
+// lacks the columns added by later versions.
+type absence struct {
+	migrations bool
+	dirty      bool
+	repeatable bool
+	columns    bool
 }
 
 type Dialect = string
@@ -38,13 +51,49 @@ type queryBuilder interface {
 	InsertMigration(tableName string) string
 	DeleteMigration(tableName string) string
 	ListMigrations(tableName string) string
+	ListLegacyMigrations(tableName string) string
 	SelectLastMigrationID(tableName string) string
+	SelectColumns(tableName string) string
+	AddChecksumColumn(tableName string) string
+	InsertBaselinedMigration(tableName string) string
+	InsertImportedMigration(tableName string) string
+	AddBaselinedColumn(tableName string) string
+
+	CreateDirtyTable(dirtyTableName string) string
+	InsertDirtyMark(dirtyTableName string) string
+	SelectDirtyMark(dirtyTableName string) string
+	DeleteDirtyMarks(dirtyTableName string) string
+
+	CreateRepeatableTable(repeatableTableName string) string
+	InsertRepeatable(repeatableTableName string) string
+	DeleteRepeatable(repeatableTableName string) string
+	ListRepeatables(repeatableTableName string) string
+}
+
+// schemaCreator is implemented by query builders of databases with schemas,
+// the schema of the migrations table is created if not exists.
+type schemaCreator interface {
+	CreateSchema() string
+}
+
+// ddlCommitter is implemented by query builders of databases that implicitly commit
+// the current transaction on DDL statements (CREATE, ALTER, DROP, etc.).
+type ddlCommitter interface {
+	CommitsDDL() bool
+}
+
+// timeFormatter is implemented by query builders of databases whose drivers don't store time.Time
+// in the format of the migrations table, the time is passed to queries formatted.
+type timeFormatter interface {
+	FormatTime(t time.Time) any
 }
 
 type MigrationResult struct {
 	ID        int64
 	Name      string
 	AppliedAt time.Time
+	Checksum  string
+	Baselined bool // The migration was marked as applied by baseline without running it.
 }
 
 type database interface {
@@ -53,14 +102,39 @@ type database interface {
 	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
 }
 
-func New(dialect Dialect, tableName string) (*Store, error) {
+// Option configures optional Store parameters.
+type Option func(o *options)
+
+type options struct {
+	schema string
+}
+
+// WithSchema sets the schema of the migrations table. Only Postgres supports schemas,
+// other dialects ignore it. The default schema is "public".
+func WithSchema(schema string) Option {
+	return func(o *options) { o.schema = schema }
+}
+
+func New(dialect Dialect, tableName string, opts ...Option) (*Store, error) {
+	o := options{schema: defaultSchemaName}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.schema == "" {
+		o.schema = defaultSchemaName
+	}
+
 	var b queryBuilder
 
 	switch dialect {
 	case Postgres:
-		b = &postgresQueryBuilder{}
+		b = &postgresQueryBuilder{schema: o.schema}
 	case ClickHouse:
 		b = &clickhouseQueryBuilder{}
+	case MySQL:
+		b = &mysqlQueryBuilder{}
+	case SQLite:
+		b = &sqliteQueryBuilder{}
 	default:
 		return nil, ErrUnsupportedDialect
 	}
@@ -71,8 +145,24 @@ func New(dialect Dialect, tableName string) (*Store, error) {
 	}, nil
 }
 
+// TableName returns the name of the migrations table.
+func (s Store) TableName() string {
+	return s.tableName
+}
+
+// CommitsDDL reports whether the database implicitly commits the current transaction on DDL statements,
+// so a transaction can't make a migration with DDL atomic.
+func (s Store) CommitsDDL() bool {
+	c, ok := s.queryManager.(ddlCommitter)
+	return ok && c.CommitsDDL()
+}
+
 func (s Store) MigrationsTableExists(ctx context.Context, db database) (bool, error) {
-	q := s.queryManager.MigrationsTableExists(s.tableName)
+	return s.tableExists(ctx, db, s.tableName)
+}
+
+func (s Store) tableExists(ctx context.Context, db database, tableName string) (bool, error) {
+	q := s.queryManager.MigrationsTableExists(tableName)
 	row := db.QueryRowContext(ctx, q)
 
 	var exists bool
@@ -84,14 +174,125 @@ func (s Store) MigrationsTableExists(ctx context.Context, db database) (bool, er
 }
 
 func (s Store) CreateMigrationsTable(ctx context.Context, db database) error {
+	if c, ok := s.queryManager.(schemaCreator); ok {
+		if _, err := db.ExecContext(ctx, c.CreateSchema()); err != nil {
+			return fmt.Errorf("failed to create schema: %w", err)
+		}
+	}
+
 	q := s.queryManager.CreateMigrationsTable(s.tableName)
 	_, err := db.ExecContext(ctx, q)
 	return err
 }
 
-func (s Store) InsertMigration(ctx context.Context, db database, migrationName string, id int64) error {
+// Inspect returns a copy of the store that reads the tables as they are, without creating or upgrading them,
+// e.g. for a dry run. Reads from a table that doesn't exist return no rows, and the columns the migrations table
+// lacks read as their zero values.
+func (s Store) Inspect(ctx context.Context, db database) (*Store, error) {
+	var a absence
+	for _, t := range []struct {
+		name   string
+		absent *bool
+	}{
+		{name: s.tableName, absent: &a.migrations},
+		{name: s.dirtyTableName(), absent: &a.dirty},
+		{name: s.repeatableTableName(), absent: &a.repeatable},
+	} {
+		exists, err := s.tableExists(ctx, db, t.name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check if table %s exists: %w", t.name, err)
+		}
+		*t.absent = !exists
+	}
+
+	if !a.migrations {
+		columns, err := s.listColumns(ctx, db)
+		if err != nil {
+			return nil, err
+		}
+		_, hasChecksum := columns["checksum"]
+		_, hasBaselined := columns["baselined"]
+		a.columns = !hasChecksum || !hasBaselined
+	}
+
+	s.absent = &a
+	return &s, nil
+}
+
+// UpgradeMigrationsTable adds columns introduced by later versions to a migrations table
+// created by an earlier one.
+func (s Store) UpgradeMigrationsTable(ctx context.Context, db database) error {
+	columns, err := s.listColumns(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	if _, exists := columns["checksum"]; !exists {
+		if _, err = db.ExecContext(ctx, s.queryManager.AddChecksumColumn(s.tableName)); err != nil {
+			return fmt.Errorf("failed to add checksum column: %w", err)
+		}
+	}
+
+	if _, exists := columns["baselined"]; !exists {
+		if _, err = db.ExecContext(ctx, s.queryManager.AddBaselinedColumn(s.tableName)); err != nil {
+			return fmt.Errorf("failed to add baselined column: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s Store) listColumns(ctx context.Context, db database) (map[string]struct{}, error) {
+	rows, err := db.QueryContext(ctx, s.queryManager.SelectColumns(s.tableName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to select migrations table columns: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get migrations table columns: %w", err)
+	}
+
+	columns := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		columns[strings.ToLower(name)] = struct{}{}
+	}
+
+	return columns, nil
+}
+
+// InsertMigration records an applied migration. The checksum of its statements is empty for Go migrations.
+func (s Store) InsertMigration(
+	ctx context.Context, db database, migrationName string, id int64, checksum string,
+) error {
 	q := s.queryManager.InsertMigration(s.tableName)
-	_, err := db.ExecContext(ctx, q, id, migrationName)
+	_, err := db.ExecContext(ctx, q, id, migrationName, checksum)
+	return err
+}
+
+// InsertBaselinedMigration records a migration marked as applied by baseline without running it.
+func (s Store) InsertBaselinedMigration(
+	ctx context.Context, db database, migrationName string, id int64, checksum string,
+) error {
+	q := s.queryManager.InsertBaselinedMigration(s.tableName)
+	_, err := db.ExecContext(ctx, q, id, migrationName, checksum)
+	return err
+}
+
+// InsertImportedMigration records a migration applied at the given time, e.g. by another migration tool.
+func (s Store) InsertImportedMigration(
+	ctx context.Context, db database, migrationName string, id int64, checksum string, appliedAt time.Time,
+) error {
+	var at any = appliedAt
+	if f, ok := s.queryManager.(timeFormatter); ok {
+		at = f.FormatTime(appliedAt)
+	}
+
+	q := s.queryManager.InsertImportedMigration(s.tableName)
+	_, err := db.ExecContext(ctx, q, id, migrationName, at, checksum)
 	return err
 }
 
@@ -102,6 +303,10 @@ func (s Store) DeleteMigration(ctx context.Context, db database, id int64) error
 }
 
 func (s Store) SelectLastID(ctx context.Context, db database) (int64, error) {
+	if s.absent != nil && s.absent.migrations {
+		return -1, ErrNoRows
+	}
+
 	q := s.queryManager.SelectLastMigrationID(s.tableName)
 	row := db.QueryRowContext(ctx, q)
 
@@ -120,7 +325,16 @@ func (s Store) SelectLastID(ctx context.Context, db database) (int64, error) {
 }
 
 func (s Store) ListMigrations(ctx context.Context, db database) ([]MigrationResult, error) {
+	if s.absent != nil && s.absent.migrations {
+		return nil, nil
+	}
+
 	q := s.queryManager.ListMigrations(s.tableName)
+	// An inspected table created by an earlier version may lack the checksum and baselined columns.
+	legacy := s.absent != nil && s.absent.columns
+	if legacy {
+		q = s.queryManager.ListLegacyMigrations(s.tableName)
+	}
 	rows, err := db.QueryContext(ctx, q)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute query listMigrations: %w", err)
@@ -134,13 +348,21 @@ func (s Store) ListMigrations(ctx context.Context, db database) ([]MigrationResu
 		var id int64
 		var name string
 		var appliedAt time.Time
-		if err = rows.Scan(&id, &name, &appliedAt); err != nil {
+		var checksum string
+		var baselined bool
+		dest := []any{&id, &name, &appliedAt, &checksum, &baselined}
+		if legacy {
+			dest = dest[:3]
+		}
+		if err = rows.Scan(dest...); err != nil {
 			return nil, fmt.Errorf("failed to scan migration result: %w", err)
 		}
 		migrations = append(migrations, MigrationResult{
 			ID:        id,
 			Name:      name,
 			AppliedAt: appliedAt,
+			Checksum:  checksum,
+			Baselined: baselined,
 		})
 	}
 	if err = rows.Err(); err != nil {
@@ -10,6 +10,7 @@ func TestNewStore(t *testing.T) {
 	type args struct {
 		dbDialect string
 		tableName string
+		opts      []Option
 	}
 	tests := map[string]struct {
 		args    args
@@ -21,8 +22,19 @@ func TestNewStore(t *testing.T) {
 				dbDialect: Postgres,
 				tableName: "migrations",
 			}, want: &Store{
-				"migrations",
-				&postgresQueryBuilder{},
+				tableName:    "migrations",
+				queryManager: &postgresQueryBuilder{schema: "public"},
+			},
+			wantErr: nil,
+		},
+		"Postgres with schema": {
+			args: args{
+				dbDialect: Postgres,
+				tableName: "migrations",
+				opts:      []Option{WithSchema("billing")},
+			}, want: &Store{
+				tableName:    "migrations",
+				queryManager: &postgresQueryBuilder{schema: "billing"},
 			},
 			wantErr: nil,
 		},
@@ -31,14 +43,34 @@ func TestNewStore(t *testing.T) {
 				dbDialect: ClickHouse,
 				tableName: "migrations",
 			}, want: &Store{
-				"migrations",
-				&clickhouseQueryBuilder{},
+				tableName:    "migrations",
+				queryManager: &clickhouseQueryBuilder{},
+			},
+			wantErr: nil,
+		},
+		"existing dialect MySQL": {
+			args: args{
+				dbDialect: MySQL,
+				tableName: "migrations",
+			}, want: &Store{
+				tableName:    "migrations",
+				queryManager: &mysqlQueryBuilder{},
+			},
+			wantErr: nil,
+		},
+		"existing dialect SQLite": {
+			args: args{
+				dbDialect: SQLite,
+				tableName: "migrations",
+			}, want: &Store{
+				tableName:    "migrations",
+				queryManager: &sqliteQueryBuilder{},
 			},
 			wantErr: nil,
 		},
 		"unknown dialect": {
 			args: args{
-				dbDialect: "mysql",
+				dbDialect: "oracle",
 				tableName: "migrations",
 			}, want: nil,
 			wantErr: ErrUnsupportedDialect,
@@ -46,9 +78,24 @@ func TestNewStore(t *testing.T) {
 	}
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			got, err := New(test.args.dbDialect, test.args.tableName)
+			got, err := New(test.args.dbDialect, test.args.tableName, test.args.opts...)
 			require.ErrorIs(t, err, test.wantErr, "New(...) error")
 			require.Equal(t, got, test.want, "New(...) new Store")
 		})
 	}
 }
+
+func TestPostgresQueryBuilderSchema(t *testing.T) {
+	t.Parallel()
+	b := &postgresQueryBuilder{schema: "billing"}
+
+	require.String(t, b.CreateSchema(), "CREATE SCHEMA IF NOT EXISTS billing", "CreateSchema()")
+	require.String(t, b.MigrationsTableExists("migrations"),
+		"SELECT EXISTS (SELECT FROM pg_tables WHERE schemaname = 'billing' AND tablename  = 'migrations')",
+		"MigrationsTableExists(...)")
+	require.String(t, b.DeleteMigration("migrations"),
+		"DELETE FROM billing.migrations WHERE id = $1", "DeleteMigration(...)")
+	if b.TryAdvisoryLock("migrations") == (&postgresQueryBuilder{schema: "public"}).TryAdvisoryLock("migrations") {
+		t.Fatalf("TryAdvisoryLock(...) must depend on schema")
+	}
+}
@@ -32,28 +32,36 @@ type GoMigrateNoTxFn func(ctx context.Context, db *sql.DB) error
 // AddMigration registers a new migration with `up` and `down` functions for handling database schema changes.
 func AddMigration(up, down GoMigrateFn) {
 	_, fileName, _, _ := runtime.Caller(1) //nolint:dogsled
-	goMigrations = append(goMigrations, goMigration{
+	goMigrations = append(goMigrations, newGoMigration(fileName, up, down))
+}
+
+// AddMigrationNoTx registers a database migration function pair that operates without transactions.
+func AddMigrationNoTx(up, down GoMigrateNoTxFn) {
+	_, fileName, _, _ := runtime.Caller(1) //nolint:dogsled
+	goMigrations = append(goMigrations, newGoMigrationNoTx(fileName, up, down))
+}
+
+func newGoMigration(fileName string, up, down GoMigrateFn) goMigration {
+	return goMigration{
 		fileName: fileName,
 		noTx:     false,
 		up:       up,
 		down:     down,
-	})
+	}
 }
 
-// AddMigrationNoTx registers a database migration function pair that operates without transactions.
-func AddMigrationNoTx(up, down GoMigrateNoTxFn) {
-	_, fileName, _, _ := runtime.Caller(1) //nolint:dogsled
-	goMigrations = append(goMigrations, goMigration{
+func newGoMigrationNoTx(fileName string, up, down GoMigrateNoTxFn) goMigration {
+	return goMigration{
 		fileName: fileName,
 		noTx:     true,
 		upNoTx:   up,
 		downNoTx: down,
-	})
+	}
 }
 
-func convertGoMigrations() (migrator.Migrations, error) {
-	result := make(migrator.Migrations, 0, len(goMigrations))
-	for _, m := range goMigrations {
+func convertGoMigrations(registered []goMigration) (migrator.Migrations, error) {
+	result := make(migrator.Migrations, 0, len(registered))
+	for _, m := range registered {
 		id, name, err := migrator.ParseMigrationFileName(m.fileName)
 		if err != nil {
 			return nil, ErrIncorrectMigrationName
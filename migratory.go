@@ -12,6 +12,20 @@ import (
 var (
 	ErrUnsupportedMigrationType = errors.New("migration type is unsupported")
 	ErrIncorrectMigrationName   = errors.New("migration name is incorrect")
+	ErrLockTimeout              = migrator.ErrLockTimeout
+	ErrNoPendingMigrations      = migrator.ErrNoPendingMigrations
+	ErrModifiedMigrations       = migrator.ErrModifiedMigrations
+	ErrDirtyDatabase            = migrator.ErrDirtyDatabase
+	ErrStatementsChanged        = migrator.ErrStatementsChanged
+	ErrNotDirty                 = migrator.ErrNotDirty
+	ErrInvalidMigrations        = migrator.ErrInvalidMigrations
+	ErrNotEmptyTable            = migrator.ErrNotEmptyTable
+	ErrMigrationNotFound        = migrator.ErrMigrationNotFound
+	ErrAlreadyApplied           = migrator.ErrAlreadyApplied
+	ErrNotApplied               = migrator.ErrNotApplied
+	ErrIrreversible             = migrator.ErrIrreversible
+	ErrUnknownImportTool        = migrator.ErrUnknownImportTool
+	ErrFailedImport             = migrator.ErrFailedImport
 )
 
 // Up applies all available database migrations in order,
@@ -24,23 +38,32 @@ func Up(db *sql.DB, opts ...OptionsFunc) (n int, err error) {
 // UpContext applies any pending database migrations using the provided context, database connection, and options.
 // It returns the number of migrations applied and any error encountered during the process.
 func UpContext(ctx context.Context, db *sql.DB, opts ...OptionsFunc) (n int, err error) {
-	option := applyOptions(opts)
-	m, err := migrator.New(ctx, db, option.dialect, option.table)
-	if err != nil {
-		return 0, err
-	}
+	return newDefaultProvider(db, opts).Up(ctx)
+}
+
+// UpTo applies pending migrations with ID less than or equal to version.
+// It returns the number of applied migrations.
+func UpTo(db *sql.DB, version int64, opts ...OptionsFunc) (n int, err error) {
+	ctx := context.Background()
+	return UpToContext(ctx, db, version, opts...)
+}
 
-	migrations, err := getMigrations(option.migrationType, option.directory)
-	if err != nil {
-		return 0, err
-	}
+// UpToContext applies pending migrations with ID less than or equal to version using the provided context.
+// It returns the number of applied migrations.
+func UpToContext(ctx context.Context, db *sql.DB, version int64, opts ...OptionsFunc) (n int, err error) {
+	return newDefaultProvider(db, opts).UpTo(ctx, version)
+}
 
-	appliedCount, err := m.Up(ctx, migrations, db, option.forceUp)
-	if err != nil {
-		return appliedCount, err
-	}
+// UpByOne applies the next pending migration. It returns ErrNoPendingMigrations if there is none.
+func UpByOne(db *sql.DB, opts ...OptionsFunc) error {
+	ctx := context.Background()
+	return UpByOneContext(ctx, db, opts...)
+}
 
-	return appliedCount, nil
+// UpByOneContext applies the next pending migration using the provided context.
+// It returns ErrNoPendingMigrations if there is none.
+func UpByOneContext(ctx context.Context, db *sql.DB, opts ...OptionsFunc) error {
+	return newDefaultProvider(db, opts).UpByOne(ctx)
 }
 
 // Down rolls back the most recently applied migration in the database.
@@ -53,7 +76,33 @@ func Down(db *sql.DB, opts ...OptionsFunc) error {
 // DownContext rolls back database migrations using the provided context, database connection,
 // and optional configuration.
 func DownContext(ctx context.Context, db *sql.DB, opts ...OptionsFunc) error {
-	return rollback(ctx, db, false, opts)
+	return newDefaultProvider(db, opts).Down(ctx)
+}
+
+// DownTo rolls back applied migrations with ID greater than version, starting from the last one.
+// It returns the number of rolled back migrations.
+func DownTo(db *sql.DB, version int64, opts ...OptionsFunc) (n int, err error) {
+	ctx := context.Background()
+	return DownToContext(ctx, db, version, opts...)
+}
+
+// DownToContext rolls back applied migrations with ID greater than version using the provided context.
+// It returns the number of rolled back migrations.
+func DownToContext(ctx context.Context, db *sql.DB, version int64, opts ...OptionsFunc) (n int, err error) {
+	return newDefaultProvider(db, opts).DownTo(ctx, version)
+}
+
+// Reset rolls back all applied migrations, starting from the last one.
+// It returns the number of rolled back migrations.
+func Reset(db *sql.DB, opts ...OptionsFunc) (n int, err error) {
+	ctx := context.Background()
+	return ResetContext(ctx, db, opts...)
+}
+
+// ResetContext rolls back all applied migrations using the provided context.
+// It returns the number of rolled back migrations.
+func ResetContext(ctx context.Context, db *sql.DB, opts ...OptionsFunc) (n int, err error) {
+	return newDefaultProvider(db, opts).Reset(ctx)
 }
 
 // Redo rolls back and re-applies the last migration in the database using the provided options.
@@ -64,18 +113,63 @@ func Redo(db *sql.DB, opts ...OptionsFunc) error {
 
 // RedoContext re-applies the most recently rolled back migration within the provided context and database connection.
 func RedoContext(ctx context.Context, db *sql.DB, opts ...OptionsFunc) error {
-	return rollback(ctx, db, true, opts)
+	return newDefaultProvider(db, opts).Redo(ctx)
 }
 
 // MigrationResult represents the result of a migration,
 // including its ID, name, application status, and applied timestamp.
+// Modified is set for an applied SQL migration whose file was changed since it was applied,
+// Dirty is set for an interrupted migration without transaction, see Repair.
 type MigrationResult struct {
 	ID        int64
 	Name      string
 	IsApplied bool
 	AppliedAt time.Time
+	Modified  bool
+	Dirty     bool
+	Baselined bool // Marked as applied by Baseline without running it.
 }
 
+// ParseError describes an error in an SQL migration file with its position,
+// Error formats it compiler-style, e.g. `01_users.sql:42:1: unknown migration command "statment_end"`.
+type ParseError = migrator.ParseError
+
+// Plan lists the migrations a dry run would apply or roll back in the order they would be run, see WithDryRun.
+type Plan = migrator.Plan
+
+// PlannedMigration is a migration a dry run would apply or roll back with its transaction mode and SQL statements.
+type PlannedMigration = migrator.PlannedMigration
+
+// Hooks are functions called before and after a run of migrations and around each migration, see WithHooks.
+type Hooks = migrator.Hooks
+
+// HookFn is a hook called around migrations.
+type HookFn = migrator.HookFn
+
+// HookInfo describes the migration a hook is called for with the transaction or database it is run on.
+type HookInfo = migrator.HookInfo
+
+// RepairAction tells Repair how an interrupted migration was resolved by hand.
+type RepairAction = migrator.RepairAction
+
+const (
+	// RepairApplied records the interrupted migration as applied.
+	RepairApplied = migrator.RepairApplied
+	// RepairRolledBack records the interrupted migration as not applied.
+	RepairRolledBack = migrator.RepairRolledBack
+)
+
+// ImportOptions selects the history of another migration tool imported by Import.
+type ImportOptions = migrator.ImportOptions
+
+// Migration tools whose history of applied migrations can be imported, see ImportOptions.
+const (
+	ImportGoose         = migrator.ImportGoose
+	ImportGolangMigrate = migrator.ImportGolangMigrate
+	ImportSQLMigrate    = migrator.ImportSQLMigrate
+	ImportFlyway        = migrator.ImportFlyway
+)
+
 // GetStatus retrieves the migration status from the database,
 // including applied status and application time for each migration.
 func GetStatus(db *sql.DB, opts ...OptionsFunc) ([]MigrationResult, error) {
@@ -86,33 +180,101 @@ func GetStatus(db *sql.DB, opts ...OptionsFunc) ([]MigrationResult, error) {
 // GetStatusContext retrieves the migration status from the database
 // and returns a list of MigrationResult with their details.
 func GetStatusContext(ctx context.Context, db *sql.DB, opts ...OptionsFunc) ([]MigrationResult, error) {
-	option := applyOptions(opts)
-	m, err := migrator.New(ctx, db, option.dialect, option.table)
-	if err != nil {
-		return nil, err
-	}
-
-	migrations, err := getMigrations(option.migrationType, option.directory)
-	if err != nil {
-		return nil, err
-	}
-
-	results, err := m.GetStatus(ctx, migrations, db)
-	if err != nil {
-		return nil, err
-	}
-
-	migrationResults := make([]MigrationResult, 0, len(results))
-	for _, r := range results {
-		migrationResults = append(migrationResults, MigrationResult{
-			ID:        r.ID,
-			Name:      r.Name,
-			IsApplied: !r.AppliedAt.IsZero(),
-			AppliedAt: r.AppliedAt,
-		})
-	}
-
-	return migrationResults, nil
+	return newDefaultProvider(db, opts).Status(ctx)
+}
+
+// Validate checks that every SQL migration can be parsed and that applied SQL migrations were not modified
+// since they were applied. It returns ErrInvalidMigrations with all parse errors (see ParseError),
+// or ErrModifiedMigrations listing the modified migrations.
+func Validate(db *sql.DB, opts ...OptionsFunc) error {
+	ctx := context.Background()
+	return ValidateContext(ctx, db, opts...)
+}
+
+// ValidateContext checks that every SQL migration can be parsed and that applied SQL migrations were not modified
+// since they were applied, using the provided context.
+func ValidateContext(ctx context.Context, db *sql.DB, opts ...OptionsFunc) error {
+	return newDefaultProvider(db, opts).Validate(ctx)
+}
+
+// ValidateFiles checks that every migration can be parsed without connecting to a database, e.g. in CI.
+// It returns ErrInvalidMigrations with all parse errors (see ParseError), modified migrations are not checked.
+func ValidateFiles(opts ...OptionsFunc) error {
+	return newDefaultProvider(nil, opts).ValidateFiles()
+}
+
+// Repair resolves a migration without transaction that was interrupted, e.g. by a crash,
+// after the database was fixed by hand. Until then Up and Down return ErrDirtyDatabase.
+// The migration is recorded as applied or rolled back according to action, ErrNotDirty is returned
+// if no migration was interrupted.
+func Repair(db *sql.DB, action RepairAction, opts ...OptionsFunc) (MigrationResult, error) {
+	ctx := context.Background()
+	return RepairContext(ctx, db, action, opts...)
+}
+
+// RepairContext resolves an interrupted migration without transaction, see Repair.
+func RepairContext(ctx context.Context, db *sql.DB, action RepairAction, opts ...OptionsFunc) (MigrationResult, error) {
+	return newDefaultProvider(db, opts).Repair(ctx, action)
+}
+
+// MarkApplied records the migration with the ID as applied without running it, e.g. when it was applied by hand.
+// It returns ErrMigrationNotFound if there is no such migration, and ErrAlreadyApplied if it's applied.
+func MarkApplied(db *sql.DB, id int64, opts ...OptionsFunc) (MigrationResult, error) {
+	ctx := context.Background()
+	return MarkAppliedContext(ctx, db, id, opts...)
+}
+
+// MarkAppliedContext records the migration with the ID as applied without running it, using the provided context.
+func MarkAppliedContext(ctx context.Context, db *sql.DB, id int64, opts ...OptionsFunc) (MigrationResult, error) {
+	return newDefaultProvider(db, opts).MarkApplied(ctx, id)
+}
+
+// MarkUnapplied deletes the record of the applied migration with the ID without rolling it back,
+// e.g. when it was reverted by hand. It returns ErrMigrationNotFound if there is no such migration,
+// and ErrNotApplied if it's not applied.
+func MarkUnapplied(db *sql.DB, id int64, opts ...OptionsFunc) (MigrationResult, error) {
+	ctx := context.Background()
+	return MarkUnappliedContext(ctx, db, id, opts...)
+}
+
+// MarkUnappliedContext deletes the record of the applied migration with the ID without rolling it back,
+// using the provided context.
+func MarkUnappliedContext(ctx context.Context, db *sql.DB, id int64, opts ...OptionsFunc) (MigrationResult, error) {
+	return newDefaultProvider(db, opts).MarkUnapplied(ctx, id)
+}
+
+// Baseline marks migrations with ID less than or equal to version as applied without running them,
+// to adopt a database created before migratory was used. They are recorded as baselined, see MigrationResult.
+// It returns ErrNotEmptyTable if the migrations table has rows, unless force is set, then only migrations
+// not applied yet are marked. It returns the number of marked migrations.
+func Baseline(db *sql.DB, version int64, force bool, opts ...OptionsFunc) (n int, err error) {
+	ctx := context.Background()
+	return BaselineContext(ctx, db, version, force, opts...)
+}
+
+// BaselineContext marks migrations with ID less than or equal to version as applied without running them,
+// using the provided context.
+func BaselineContext(
+	ctx context.Context, db *sql.DB, version int64, force bool, opts ...OptionsFunc,
+) (n int, err error) {
+	return newDefaultProvider(db, opts).Baseline(ctx, version, force)
+}
+
+// Import records migrations applied by another migration tool (goose, golang-migrate, sql-migrate or Flyway)
+// as applied with the time they were applied at, read from the version table of the tool.
+// It returns ErrNotEmptyTable if the migrations table has rows, unless importOpts.Force is set,
+// then only migrations not applied yet are recorded.
+// It returns the imported migrations, with ImportOptions.DryRun they are returned without being recorded.
+func Import(db *sql.DB, importOpts ImportOptions, opts ...OptionsFunc) ([]MigrationResult, error) {
+	ctx := context.Background()
+	return ImportContext(ctx, db, importOpts, opts...)
+}
+
+// ImportContext records migrations applied by another migration tool as applied using the provided context.
+func ImportContext(
+	ctx context.Context, db *sql.DB, importOpts ImportOptions, opts ...OptionsFunc,
+) ([]MigrationResult, error) {
+	return newDefaultProvider(db, opts).Import(ctx, importOpts)
 }
 
 // GetDBVersion retrieves the current database schema version based on the migrations table.
@@ -127,47 +289,5 @@ func GetDBVersion(db *sql.DB, opts ...OptionsFunc) (int64, error) {
 // GetDBVersionContext retrieves the current database version by querying the migrations metadata table.
 // The database version is represented by the ID of the last applied migration.
 func GetDBVersionContext(ctx context.Context, db *sql.DB, opts ...OptionsFunc) (int64, error) {
-	option := applyOptions(opts)
-	m, err := migrator.New(ctx, db, option.dialect, option.table)
-	if err != nil {
-		return -1, err
-	}
-
-	version, err := m.GetDBVersion(ctx, db)
-	if err != nil {
-		return -1, err
-	}
-
-	return version, nil
-}
-
-func getMigrations(migrationType, directory string) (m migrator.Migrations, err error) {
-	switch migrationType {
-	case migrationTypeGo:
-		m, err = convertGoMigrations()
-	case migrationTypeSQL:
-		m, err = migrator.SeekMigrations(directory)
-	default:
-		return nil, ErrUnsupportedMigrationType
-	}
-	return m, err
-}
-
-func rollback(ctx context.Context, db *sql.DB, redo bool, opts []OptionsFunc) error {
-	option := applyOptions(opts)
-	m, err := migrator.New(ctx, db, option.dialect, option.table)
-	if err != nil {
-		return err
-	}
-
-	migrations, err := getMigrations(option.migrationType, option.directory)
-	if err != nil {
-		return err
-	}
-
-	if err = m.Down(ctx, migrations, db, redo); err != nil {
-		return err
-	}
-
-	return nil
+	return newDefaultProvider(db, opts).Version(ctx)
 }
@@ -1,45 +1,80 @@
 package migratory
 
-import "github.com/evgodev/migratory/internal/migrator"
+import (
+	"io/fs"
+	"log/slog"
+	"time"
+
+	"github.com/evgodev/migratory/internal/migrator"
+)
 
 const (
 	Postgres   Dialect = migrator.Postgres
 	ClickHouse Dialect = migrator.ClickHouse
+	MySQL      Dialect = migrator.MySQL
+	SQLite     Dialect = migrator.SQLite
+)
 
-	migrationTypeGo  = "go"
-	migrationTypeSQL = "sql"
+// Sources of migrations, see WithMigrationSources.
+const (
+	GoMigrations  MigrationSource = "go"
+	SQLMigrations MigrationSource = "sql"
 )
 
-var defaultOpts = options{
-	migrationType: migrationTypeGo,
-	dialect:       Postgres,
-	directory:     ".",
-	table:         "migrations",
-	forceUp:       false,
-}
+var defaultOpts = baseOptions()
 
 // Dialect determines how the migrations table is managed based on the database system.
 type Dialect = string
 
+// MigrationSource is a kind of migrations the migrator collects: GoMigrations or SQLMigrations.
+type MigrationSource = string
+
 type options struct {
-	migrationType string
+	migrationType MigrationSource
+	sources       []MigrationSource // Set by WithMigrationSources, overrides migrationType.
 	directory     string
+	fsys          fs.FS // nil means the OS file system.
 	dialect       string
 	table         string
+	schema        string
+
+	forceUp           bool
+	validateChecksums bool
+	preflight         bool
+	plan              *Plan
 
-	forceUp bool
+	lock        bool
+	lockTimeout time.Duration
+	lockTTL     time.Duration
+
+	logger *slog.Logger
+	hooks  *Hooks
 }
 
 type OptionsFunc func(o *options)
 
-// WithGoMigration sets the migration type to "go" for the specified options configuration.
+// WithGoMigration sets the migration type to "go", registered Go migrations are used.
 func WithGoMigration() OptionsFunc {
-	return func(o *options) { o.migrationType = migrationTypeGo }
+	return func(o *options) { o.migrationType = GoMigrations }
 }
 
 // WithSQLMigrationDir sets the migration type to SQL and specifies the directory containing migration files.
 func WithSQLMigrationDir(d string) OptionsFunc {
-	return func(o *options) { o.migrationType = migrationTypeSQL; o.directory = d }
+	return func(o *options) { o.migrationType = SQLMigrations; o.directory = d; o.fsys = nil }
+}
+
+// WithSQLMigrationFS sets the migration type to SQL with migrations read from the directory dir of fsys,
+// e.g. an embed.FS. The directory must be a valid fs.FS path, use "." for the root of fsys.
+func WithSQLMigrationFS(fsys fs.FS, dir string) OptionsFunc {
+	return func(o *options) { o.migrationType = SQLMigrations; o.directory = dir; o.fsys = fsys }
+}
+
+// WithMigrationSources collects migrations from all the given sources and merges them into one run
+// ordered by ID, e.g. WithMigrationSources(GoMigrations, SQLMigrations) applies registered Go migrations
+// together with SQL migrations of the directory set by WithSQLMigrationDir or SetSQLDirectory.
+// It overrides the migration type set by WithGoMigration and WithSQLMigrationDir regardless of the order.
+func WithMigrationSources(sources ...MigrationSource) OptionsFunc {
+	return func(o *options) { o.sources = sources }
 }
 
 // WithTable configures a custom table name for tracking migrations within the database.
@@ -47,6 +82,12 @@ func WithTable(n string) OptionsFunc {
 	return func(o *options) { o.table = n }
 }
 
+// WithSchema sets the Postgres schema of the migrations table, "public" by default.
+// The schema is created if it doesn't exist. Other dialects ignore it.
+func WithSchema(s string) OptionsFunc {
+	return func(o *options) { o.schema = s }
+}
+
 // WithForce allows the migrator to apply any unapplied migrations out of their original sequence,
 // potentially resulting in migrations being applied in a non-linear order.
 // For example, if there are migrations with numbers 1, 2, 3, and migration 2 was not applied before,
@@ -55,6 +96,64 @@ func WithForce() OptionsFunc {
 	return func(o *options) { o.forceUp = true }
 }
 
+// WithChecksumValidation makes Up, UpTo and UpByOne refuse to apply migrations with ErrModifiedMigrations
+// if any applied SQL migration was modified since it was applied. Use Validate to check it separately.
+func WithChecksumValidation() OptionsFunc {
+	return func(o *options) { o.validateChecksums = true }
+}
+
+// WithPreflight makes Up, UpTo and UpByOne parse every pending SQL migration before applying any.
+// If some of them are invalid, nothing is applied and ErrInvalidMigrations with all parse errors is returned.
+// Without it, SQL migrations are parsed one by one, right before they are applied.
+func WithPreflight() OptionsFunc {
+	return func(o *options) { o.preflight = true }
+}
+
+// WithDryRun makes Up, UpTo, UpByOne, Down, DownTo, Reset and Redo fill plan with the migrations they would
+// apply or roll back, in order, with their transaction mode and SQL statements, instead of running them.
+// The database is not changed, not even the migrations table is created, the returned counts are the planned ones.
+// Baseline returns the number of migrations it would mark without marking them, MarkApplied, MarkUnapplied
+// and Repair return the migration they would record without recording it.
+func WithDryRun(plan *Plan) OptionsFunc {
+	return func(o *options) { o.plan = plan }
+}
+
+// WithLock makes the migrator hold a lock while applying or rolling back migrations,
+// so that several processes started at once (e.g. replicas of one service) don't apply the same migrations.
+// Postgres uses a session-level advisory lock. Databases without advisory locks (e.g. ClickHouse)
+// use a row in the "<table>_lock" table, which is taken over when it becomes older than the lock TTL.
+func WithLock() OptionsFunc {
+	return func(o *options) { o.lock = true }
+}
+
+// WithLockTimeout enables the migrations lock (see WithLock) and sets the maximum time
+// to wait for a lock held by another process. The default timeout is 5 minutes.
+func WithLockTimeout(d time.Duration) OptionsFunc {
+	return func(o *options) { o.lock = true; o.lockTimeout = d }
+}
+
+// WithLockTTL enables the migrations lock (see WithLock) and sets the age after which a lock row
+// is considered stale, e.g. left by a crashed process, and is taken over. The process holding the lock
+// refreshes its row several times within TTL, so a run may last longer. The default TTL is 30 minutes.
+// Advisory locks don't need it, they are released by the database when the session ends.
+func WithLockTTL(d time.Duration) OptionsFunc {
+	return func(o *options) { o.lock = true; o.lockTTL = d }
+}
+
+// WithLogger makes the migrator log creation of the migrations table and every applied or rolled back migration
+// with its ID, name, direction, transaction mode and duration. SQL statements are logged at debug level.
+// The library logs nothing by default.
+func WithLogger(l *slog.Logger) OptionsFunc {
+	return func(o *options) { o.logger = l }
+}
+
+// WithHooks sets functions called around migration runs: BeforeAll and AfterAll around the whole run,
+// BeforeEach and AfterEach around every migration, in its transaction if it has one, and OnError when
+// a migration fails. An error of BeforeAll or BeforeEach aborts the run. Dry runs don't call hooks.
+func WithHooks(h Hooks) OptionsFunc {
+	return func(o *options) { o.hooks = &h }
+}
+
 // WithDialect sets the database dialect.
 func WithDialect(d Dialect) OptionsFunc {
 	return func(o *options) { o.dialect = d }
@@ -65,8 +164,9 @@ func SetTable(s string) { defaultOpts.table = s }
 
 // SetSQLDirectory configures the default options to use the specified directory for SQL migration files.
 func SetSQLDirectory(path string) {
-	defaultOpts.migrationType = migrationTypeSQL
+	defaultOpts.migrationType = SQLMigrations
 	defaultOpts.directory = path
+	defaultOpts.fsys = nil
 }
 
 // SetDialect sets the default SQL dialect for database migrations.
@@ -76,6 +176,17 @@ func SetDialect(d Dialect) {
 	defaultOpts.dialect = d
 }
 
+// baseOptions returns the library defaults, unaffected by the Set* functions.
+func baseOptions() options {
+	return options{
+		migrationType: GoMigrations,
+		dialect:       Postgres,
+		directory:     ".",
+		table:         "migrations",
+		forceUp:       false,
+	}
+}
+
 func applyOptions(optionsFns []OptionsFunc) options {
 	opts := defaultOpts
 	for _, apply := range optionsFns {
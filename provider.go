@@ -0,0 +1,437 @@
+package migratory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"runtime"
+
+	"github.com/evgodev/migratory/internal/migrator"
+)
+
+var ErrNilDB = errors.New("database connection is nil")
+
+// Provider applies and rolls back migrations of a single database.
+// Unlike the package-level functions, a Provider owns its registry of Go migrations and its options,
+// so one binary can migrate several databases with different migration sets, and providers
+// can be used in parallel tests. Go migrations must be registered before the provider is used.
+type Provider struct {
+	db           *sql.DB
+	opts         options
+	goMigrations []goMigration
+}
+
+// NewProvider creates a Provider for the given database connection and dialect.
+// Options are applied on top of the library defaults and are not affected by SetTable, SetDialect
+// or SetSQLDirectory. Go migrations registered with the package-level AddMigration are not used,
+// register them with Provider.AddMigration and Provider.AddMigrationNoTx instead.
+func NewProvider(db *sql.DB, dialect Dialect, opts ...OptionsFunc) (*Provider, error) {
+	if db == nil {
+		return nil, ErrNilDB
+	}
+
+	option := baseOptions()
+	option.dialect = dialect
+	for _, apply := range opts {
+		apply(&option)
+	}
+
+	return &Provider{
+		db:   db,
+		opts: option,
+	}, nil
+}
+
+// newDefaultProvider creates a Provider backed by the package-level registry and default options.
+func newDefaultProvider(db *sql.DB, opts []OptionsFunc) *Provider {
+	return &Provider{
+		db:           db,
+		opts:         applyOptions(opts),
+		goMigrations: goMigrations,
+	}
+}
+
+// AddMigration registers a new migration with `up` and `down` functions in the provider.
+// The migration ID and name are taken from the name of the file the function is called from.
+func (p *Provider) AddMigration(up, down GoMigrateFn) {
+	_, fileName, _, _ := runtime.Caller(1) //nolint:dogsled
+	p.goMigrations = append(p.goMigrations, newGoMigration(fileName, up, down))
+}
+
+// AddMigrationNoTx registers a migration function pair that operates without transactions in the provider.
+// The migration ID and name are taken from the name of the file the function is called from.
+func (p *Provider) AddMigrationNoTx(up, down GoMigrateNoTxFn) {
+	_, fileName, _, _ := runtime.Caller(1) //nolint:dogsled
+	p.goMigrations = append(p.goMigrations, newGoMigrationNoTx(fileName, up, down))
+}
+
+// Up applies all pending migrations and returns the number of applied ones.
+func (p *Provider) Up(ctx context.Context) (n int, err error) {
+	m, migrations, err := p.prepare(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return m.Up(ctx, migrations, p.db, p.opts.forceUp)
+}
+
+// UpTo applies pending migrations with ID less than or equal to version
+// and returns the number of applied ones.
+func (p *Provider) UpTo(ctx context.Context, version int64) (n int, err error) {
+	m, migrations, err := p.prepare(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return m.UpTo(ctx, migrations, p.db, p.opts.forceUp, version)
+}
+
+// UpByOne applies the next pending migration. It returns ErrNoPendingMigrations if there is none.
+func (p *Provider) UpByOne(ctx context.Context) error {
+	m, migrations, err := p.prepare(ctx)
+	if err != nil {
+		return err
+	}
+
+	return m.UpByOne(ctx, migrations, p.db, p.opts.forceUp)
+}
+
+// Down rolls back the most recently applied migration.
+func (p *Provider) Down(ctx context.Context) error {
+	return p.rollback(ctx, false)
+}
+
+// Redo rolls back and re-applies the most recently applied migration.
+func (p *Provider) Redo(ctx context.Context) error {
+	return p.rollback(ctx, true)
+}
+
+// DownTo rolls back applied migrations with ID greater than version, starting from the last one,
+// and returns the number of rolled back ones.
+func (p *Provider) DownTo(ctx context.Context, version int64) (n int, err error) {
+	m, migrations, err := p.prepare(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return m.DownTo(ctx, migrations, p.db, version)
+}
+
+// Reset rolls back all applied migrations and returns the number of rolled back ones.
+func (p *Provider) Reset(ctx context.Context) (n int, err error) {
+	m, migrations, err := p.prepare(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return m.Reset(ctx, migrations, p.db)
+}
+
+// Status returns the status of every known migration, applied or not, ordered by ID.
+func (p *Provider) Status(ctx context.Context) ([]MigrationResult, error) {
+	m, migrations, err := p.prepare(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := m.GetStatus(ctx, migrations, p.db)
+	if err != nil {
+		return nil, err
+	}
+
+	migrationResults := make([]MigrationResult, 0, len(results))
+	for _, r := range results {
+		migrationResults = append(migrationResults, MigrationResult{
+			ID:        r.ID,
+			Name:      r.Name,
+			IsApplied: !r.AppliedAt.IsZero(),
+			AppliedAt: r.AppliedAt,
+			Modified:  r.Modified,
+			Dirty:     r.Dirty,
+			Baselined: r.Baselined,
+		})
+	}
+
+	return migrationResults, nil
+}
+
+// Validate checks that every SQL migration can be parsed and that applied SQL migrations were not modified
+// since they were applied. It returns ErrInvalidMigrations with all parse errors (see ParseError),
+// or ErrModifiedMigrations listing the modified migrations.
+func (p *Provider) Validate(ctx context.Context) error {
+	m, migrations, err := p.prepare(ctx)
+	if err != nil {
+		return err
+	}
+
+	return m.Validate(ctx, migrations, p.db)
+}
+
+// ValidateFiles checks that every migration can be parsed without connecting to the database, e.g. in CI.
+// SQL migrations, repeatable migrations and callback files are parsed. It returns ErrInvalidMigrations
+// with all parse errors (see ParseError). Unlike Validate, it doesn't check for modified migrations.
+func (p *Provider) ValidateFiles() error {
+	migrations, err := p.getMigrations()
+	if err != nil {
+		return err
+	}
+
+	var callbacks *migrator.Callbacks
+	if p.usesSQL() {
+		if callbacks, err = p.seekSQLCallbacks(); err != nil {
+			return err
+		}
+		repeatables, err := p.seekSQLRepeatables()
+		if err != nil {
+			return err
+		}
+		migrations = append(migrations, repeatables...)
+	}
+
+	if err = migrator.PrepareMigrations(migrations); err != nil {
+		return err
+	}
+	return callbacks.Parse()
+}
+
+// Repair resolves an interrupted migration without transaction after the database was fixed by hand,
+// recording it as applied or rolled back according to action. It returns ErrNotDirty if there is none.
+func (p *Provider) Repair(ctx context.Context, action RepairAction) (MigrationResult, error) {
+	m, migrations, err := p.prepare(ctx)
+	if err != nil {
+		return MigrationResult{}, err
+	}
+
+	r, err := m.Repair(ctx, migrations, p.db, action)
+	if err != nil {
+		return MigrationResult{}, err
+	}
+
+	return MigrationResult{
+		ID:        r.ID,
+		Name:      r.Name,
+		IsApplied: action == RepairApplied,
+	}, nil
+}
+
+// MarkApplied records the migration with the ID as applied without running it, e.g. when it was applied by hand.
+// It returns ErrMigrationNotFound if there is no such migration, and ErrAlreadyApplied if it's applied.
+func (p *Provider) MarkApplied(ctx context.Context, id int64) (MigrationResult, error) {
+	return p.mark(ctx, id, true)
+}
+
+// MarkUnapplied deletes the record of the applied migration with the ID without rolling it back,
+// e.g. when it was reverted by hand. It returns ErrMigrationNotFound if there is no such migration,
+// and ErrNotApplied if it's not applied.
+func (p *Provider) MarkUnapplied(ctx context.Context, id int64) (MigrationResult, error) {
+	return p.mark(ctx, id, false)
+}
+
+// Baseline marks migrations with ID less than or equal to version as applied without running them,
+// and returns the number of marked ones. It returns ErrNotEmptyTable if the migrations table has rows,
+// unless force is set, then only migrations not applied yet are marked.
+func (p *Provider) Baseline(ctx context.Context, version int64, force bool) (n int, err error) {
+	m, migrations, err := p.prepare(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return m.Baseline(ctx, migrations, p.db, version, force)
+}
+
+// Import records migrations applied by another migration tool as applied with the time they were applied at,
+// and returns them. With ImportOptions.DryRun they are returned without being recorded. It returns
+// ErrNotEmptyTable if the migrations table has rows, unless ImportOptions.Force is set, then only migrations
+// not applied yet are recorded.
+func (p *Provider) Import(ctx context.Context, importOpts ImportOptions) ([]MigrationResult, error) {
+	m, migrations, err := p.prepare(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := m.Import(ctx, migrations, p.db, importOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	imported := make([]MigrationResult, 0, len(results))
+	for _, r := range results {
+		imported = append(imported, MigrationResult{
+			ID:        r.ID,
+			Name:      r.Name,
+			IsApplied: !importOpts.DryRun,
+			AppliedAt: r.AppliedAt,
+		})
+	}
+
+	return imported, nil
+}
+
+// Version returns the database version, which is the ID of the last applied migration.
+func (p *Provider) Version(ctx context.Context) (int64, error) {
+	m, err := p.newMigrator(ctx)
+	if err != nil {
+		return -1, err
+	}
+
+	return m.GetDBVersion(ctx, p.db)
+}
+
+func (p *Provider) mark(ctx context.Context, id int64, applied bool) (MigrationResult, error) {
+	m, migrations, err := p.prepare(ctx)
+	if err != nil {
+		return MigrationResult{}, err
+	}
+
+	mark := m.MarkUnapplied
+	if applied {
+		mark = m.MarkApplied
+	}
+
+	r, err := mark(ctx, migrations, p.db, id)
+	if err != nil {
+		return MigrationResult{}, err
+	}
+
+	return MigrationResult{
+		ID:        r.ID,
+		Name:      r.Name,
+		IsApplied: applied,
+	}, nil
+}
+
+func (p *Provider) rollback(ctx context.Context, redo bool) error {
+	m, migrations, err := p.prepare(ctx)
+	if err != nil {
+		return err
+	}
+
+	return m.Down(ctx, migrations, p.db, redo)
+}
+
+func (p *Provider) prepare(ctx context.Context) (*migrator.Migrator, migrator.Migrations, error) {
+	m, err := p.newMigrator(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	migrations, err := p.getMigrations()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return m, migrations, nil
+}
+
+func (p *Provider) newMigrator(ctx context.Context) (*migrator.Migrator, error) {
+	var opts []migrator.Option
+	if p.opts.schema != "" {
+		opts = append(opts, migrator.WithSchema(p.opts.schema))
+	}
+	if p.opts.validateChecksums {
+		opts = append(opts, migrator.WithChecksumValidation())
+	}
+	if p.opts.preflight {
+		opts = append(opts, migrator.WithPreflight())
+	}
+	if p.opts.plan != nil {
+		opts = append(opts, migrator.WithDryRun(p.opts.plan))
+	}
+	if p.opts.logger != nil {
+		opts = append(opts, migrator.WithLogger(p.opts.logger))
+	}
+	if p.opts.hooks != nil {
+		opts = append(opts, migrator.WithHooks(*p.opts.hooks))
+	}
+	if p.usesSQL() {
+		callbacks, err := p.seekSQLCallbacks()
+		if err != nil {
+			return nil, err
+		}
+		repeatables, err := p.seekSQLRepeatables()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, migrator.WithCallbacks(callbacks), migrator.WithRepeatables(repeatables))
+	}
+	if p.opts.lock {
+		opts = append(opts, migrator.WithLock(migrator.LockOptions{
+			Timeout: p.opts.lockTimeout,
+			TTL:     p.opts.lockTTL,
+		}))
+	}
+
+	return migrator.New(ctx, p.db, p.opts.dialect, p.opts.table, opts...)
+}
+
+// sources returns the sources migrations are collected from: the ones set by WithMigrationSources,
+// or the single source of the migration type.
+func (p *Provider) sources() []MigrationSource {
+	if len(p.opts.sources) > 0 {
+		return p.opts.sources
+	}
+	return []MigrationSource{p.opts.migrationType}
+}
+
+// usesSQL reports whether SQL migrations are collected.
+func (p *Provider) usesSQL() bool {
+	for _, source := range p.sources() {
+		if source == SQLMigrations {
+			return true
+		}
+	}
+	return false
+}
+
+// getMigrations collects migrations from every source and merges them into one list ordered by ID.
+func (p *Provider) getMigrations() (migrator.Migrations, error) {
+	var collected []migrator.Migrations
+	seen := make(map[MigrationSource]bool)
+	for _, source := range p.sources() {
+		if seen[source] {
+			continue
+		}
+		seen[source] = true
+
+		var m migrator.Migrations
+		var err error
+		switch source {
+		case GoMigrations:
+			m, err = convertGoMigrations(p.goMigrations)
+		case SQLMigrations:
+			m, err = p.seekSQLMigrations()
+		default:
+			return nil, fmt.Errorf("%w: %q", ErrUnsupportedMigrationType, source)
+		}
+		if err != nil {
+			return nil, err
+		}
+		collected = append(collected, m)
+	}
+
+	return migrator.MergeMigrations(collected...)
+}
+
+func (p *Provider) seekSQLMigrations() (migrator.Migrations, error) {
+	if p.opts.fsys != nil {
+		return migrator.SeekMigrationsFS(p.opts.fsys, p.opts.directory, p.opts.dialect)
+	}
+	return migrator.SeekMigrations(p.opts.directory, p.opts.dialect)
+}
+
+// seekSQLRepeatables finds repeatable migrations (R_<name>.sql) in the directory of SQL migrations.
+func (p *Provider) seekSQLRepeatables() (migrator.Migrations, error) {
+	if p.opts.fsys != nil {
+		return migrator.SeekRepeatablesFS(p.opts.fsys, p.opts.directory, p.opts.dialect)
+	}
+	return migrator.SeekRepeatables(p.opts.directory, p.opts.dialect)
+}
+
+// seekSQLCallbacks finds SQL callback files (e.g. beforeMigrate.sql) in the directory of SQL migrations.
+func (p *Provider) seekSQLCallbacks() (*migrator.Callbacks, error) {
+	if p.opts.fsys != nil {
+		return migrator.SeekCallbacksFS(p.opts.fsys, p.opts.directory, p.opts.dialect)
+	}
+	return migrator.SeekCallbacks(p.opts.directory, p.opts.dialect)
+}
@@ -0,0 +1,34 @@
+package test
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/evgodev/migratory/internal/require"
+)
+
+var migrationsTable = "go_migrations"
+
+func getLastMigrationResult(t *testing.T, db *sql.DB) (id int64, at time.Time) {
+	t.Helper()
+	q := "SELECT id, applied_at FROM %s ORDER BY id DESC LIMIT 1;"
+	rows, err := db.Query(fmt.Sprintf(q, migrationsTable))
+	require.NoError(t, err, "db.Query(...) last id error")
+	require.NoError(t, rows.Err(), "rows.Err() error")
+	defer rows.Close()
+
+	for rows.Next() {
+		err = rows.Scan(&id, &at)
+		require.NoError(t, err, "rows.Scan() last id error")
+	}
+	return
+}
+
+func getMigrationsTableRowCount(t *testing.T, db *sql.DB) (n int) {
+	t.Helper()
+	row := db.QueryRow(fmt.Sprintf("SELECT COUNT(1) FROM %s;", migrationsTable))
+	require.NoError(t, row.Scan(&n), "db.QueryRow(...) migrations count error")
+	return
+}
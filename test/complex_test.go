@@ -5,7 +5,6 @@ package test
 import (
 	"database/sql"
 	"flag"
-	"fmt"
 	"testing"
 	"time"
 
@@ -21,8 +20,6 @@ import (
 // Do not use production DB, public schema will be dropped!
 var dsn = flag.String("dsn", "", "")
 
-var migrationsTable = "go_migrations"
-
 const (
 	lastTestMigrationID int64 = 3
 	testMigrationsCount int   = 3
@@ -109,28 +106,6 @@ func TestGoMigrations(t *testing.T) {
 	require.ErrorIs(t, err, migrator.ErrNothingToRollback, "migratory.Down(...) error")
 }
 
-func getLastMigrationResult(t *testing.T, db *sql.DB) (id int64, at time.Time) {
-	t.Helper()
-	q := "SELECT id, applied_at FROM %s ORDER BY id DESC LIMIT 1;"
-	rows, err := db.Query(fmt.Sprintf(q, migrationsTable))
-	require.NoError(t, err, "db.Query(...) last id error")
-	require.NoError(t, rows.Err(), "rows.Err() error")
-	defer rows.Close()
-
-	for rows.Next() {
-		err = rows.Scan(&id, &at)
-		require.NoError(t, err, "rows.Scan() last id error")
-	}
-	return
-}
-
-func getMigrationsTableRowCount(t *testing.T, db *sql.DB) (n int) {
-	t.Helper()
-	row := db.QueryRow(fmt.Sprintf("SELECT COUNT(1) FROM %s;", migrationsTable))
-	require.NoError(t, row.Scan(&n), "db.QueryRow(...) migrations count error")
-	return
-}
-
 func getTableCount(t *testing.T, db *sql.DB) (n int) {
 	t.Helper()
 	q := "SELECT COUNT(table_name) FROM information_schema.tables WHERE table_schema = 'public';"
@@ -0,0 +1,1186 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/evgodev/migratory"
+	"github.com/evgodev/migratory/internal/migrator"
+	"github.com/evgodev/migratory/internal/require"
+	_ "github.com/evgodev/migratory/test/testdata"
+
+	_ "modernc.org/sqlite"
+)
+
+const (
+	sqlMigrationsDir         = "testdata/sql"
+	lastMixedMigrationID     = 5
+	mixedMigrationsCount int = 5
+)
+
+// TestSQLiteMigrations runs Go and SQL test migrations against SQLite, no external database is needed.
+func TestSQLiteMigrations(t *testing.T) {
+	db := setupSQLiteDB(t)
+	opts := []migratory.OptionsFunc{
+		migratory.WithDialect(migratory.SQLite),
+		migratory.WithTable(migrationsTable),
+		migratory.WithSQLMigrationDir(sqlMigrationsDir),
+		migratory.WithMigrationSources(migratory.GoMigrations, migratory.SQLMigrations),
+		migratory.WithLock(),
+	}
+
+	appliedCount, err := migratory.Up(db, opts...)
+	require.NoError(t, err, "migratory.Up(...) error")
+	require.Int(t, appliedCount, mixedMigrationsCount, "migratory.Up(...) applied migrations count")
+
+	for _, table := range []string{"orders", "dictionary", "users", "products", "reviews"} {
+		require.Bool(t, sqliteTableExists(t, db, table), true, "table "+table+" must be created")
+	}
+
+	appliedCount, err = migratory.Up(db, opts...)
+	require.NoError(t, err, "migratory.Up(...) second run error")
+	require.Int(t, appliedCount, 0, "migratory.Up(...) second run must apply nothing")
+
+	dbVersion, err := migratory.GetDBVersion(db, opts...)
+	require.NoError(t, err, "migratory.GetDBVersion(...) error")
+	require.Int64(t, dbVersion, lastMixedMigrationID, "dbVersion doesn't match the last migration ID")
+
+	results, err := migratory.GetStatus(db, opts...)
+	require.NoError(t, err, "migratory.GetStatus(...) error")
+	require.Int(t, len(results), mixedMigrationsCount, "migratory.GetStatus(...) results count")
+	for _, result := range results {
+		require.Bool(t, result.IsApplied, true, "all migrations must be applied")
+		require.Bool(t, result.AppliedAt.IsZero(), false, "applied_at must not be zero")
+	}
+
+	dbMaxID, dbAppliedAt := getLastMigrationResult(t, db)
+	err = migratory.Redo(db, opts...)
+	require.NoError(t, err, "migratory.Redo(...) error")
+
+	newDBMaxID, newDBAppliedAt := getLastMigrationResult(t, db)
+	require.Int64(t, newDBMaxID, dbMaxID, "last migration ID must not change after migratory.Redo()")
+	if !newDBAppliedAt.After(dbAppliedAt) {
+		t.Fatalf("applied_at of last migration must increase after migratory.Redo(...)")
+	}
+
+	for i := 1; i <= mixedMigrationsCount; i++ {
+		err = migratory.Down(db, opts...)
+		require.NoError(t, err, "migratory.Down(...) error")
+
+		migrationsCount := getMigrationsTableRowCount(t, db)
+		require.Int(t, migrationsCount, mixedMigrationsCount-i,
+			"migratory.Down(...) migrationsCount must decrease during rollbacks")
+	}
+	require.Bool(t, sqliteTableExists(t, db, "orders"), false, "table orders must be dropped")
+
+	err = migratory.Down(db, opts...)
+	require.ErrorIs(t, err, migrator.ErrNothingToRollback, "migratory.Down(...) error")
+}
+
+// TestSQLiteProviders ensures providers don't share migrations and options.
+func TestSQLiteProviders(t *testing.T) {
+	db := setupSQLiteDB(t)
+	ctx := context.Background()
+
+	sqlProvider, err := migratory.NewProvider(db, migratory.SQLite,
+		migratory.WithTable("sql_migrations"), migratory.WithSQLMigrationDir(sqlMigrationsDir))
+	require.NoError(t, err, "migratory.NewProvider(...) SQL provider")
+
+	emptyProvider, err := migratory.NewProvider(db, migratory.SQLite, migratory.WithTable("go_migrations"))
+	require.NoError(t, err, "migratory.NewProvider(...) Go provider")
+
+	appliedCount, err := sqlProvider.Up(ctx)
+	require.NoError(t, err, "sqlProvider.Up(...) error")
+	require.Int(t, appliedCount, 2, "sqlProvider.Up(...) applied migrations count")
+
+	// Go migrations registered with package-level AddMigration are not used by providers.
+	appliedCount, err = emptyProvider.Up(ctx)
+	require.NoError(t, err, "emptyProvider.Up(...) error")
+	require.Int(t, appliedCount, 0, "emptyProvider.Up(...) applied migrations count")
+
+	_, err = emptyProvider.Version(ctx)
+	require.ErrorIs(t, err, migrator.ErrUnknownDBVersion, "emptyProvider.Version(...) error")
+
+	version, err := sqlProvider.Version(ctx)
+	require.NoError(t, err, "sqlProvider.Version(...) error")
+	require.Int64(t, version, lastMixedMigrationID, "sqlProvider.Version(...) version")
+}
+
+// TestSQLiteMigrationSources checks that the last migration type option wins,
+// and that WithMigrationSources merges sources regardless of the order of options.
+func TestSQLiteMigrationSources(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		opts    []migratory.OptionsFunc
+		want    int
+		wantErr error
+	}{
+		"go by default": {
+			opts: nil,
+			want: 3,
+		},
+		"last option wins": {
+			opts: []migratory.OptionsFunc{migratory.WithSQLMigrationDir(sqlMigrationsDir), migratory.WithGoMigration()},
+			want: 3,
+		},
+		"sql after go": {
+			opts: []migratory.OptionsFunc{migratory.WithGoMigration(), migratory.WithSQLMigrationDir(sqlMigrationsDir)},
+			want: 2,
+		},
+		"merged sources before directory": {
+			opts: []migratory.OptionsFunc{
+				migratory.WithMigrationSources(migratory.GoMigrations, migratory.SQLMigrations),
+				migratory.WithSQLMigrationDir(sqlMigrationsDir),
+			},
+			want: mixedMigrationsCount,
+		},
+		"unsupported source": {
+			opts:    []migratory.OptionsFunc{migratory.WithMigrationSources("yaml")},
+			wantErr: migratory.ErrUnsupportedMigrationType,
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			db := setupSQLiteDB(t)
+			opts := append([]migratory.OptionsFunc{migratory.WithDialect(migratory.SQLite)}, test.opts...)
+
+			appliedCount, err := migratory.Up(db, opts...)
+			require.ErrorIs(t, err, test.wantErr, "migratory.Up(...) error")
+			require.Int(t, appliedCount, test.want, "migratory.Up(...) applied migrations count")
+		})
+	}
+}
+
+// TestSQLiteTargetVersions checks migrating up and down to target versions.
+func TestSQLiteTargetVersions(t *testing.T) {
+	db := setupSQLiteDB(t)
+	ctx := context.Background()
+
+	p, err := migratory.NewProvider(db, migratory.SQLite, migratory.WithSQLMigrationDir(sqlMigrationsDir))
+	require.NoError(t, err, "migratory.NewProvider(...) error")
+
+	appliedCount, err := p.UpTo(ctx, 3)
+	require.NoError(t, err, "p.UpTo(...) below the first migration error")
+	require.Int(t, appliedCount, 0, "p.UpTo(...) below the first migration applied count")
+
+	err = p.UpByOne(ctx)
+	require.NoError(t, err, "p.UpByOne(...) error")
+	version, err := p.Version(ctx)
+	require.NoError(t, err, "p.Version(...) error")
+	require.Int64(t, version, 4, "p.Version(...) after p.UpByOne(...)")
+
+	appliedCount, err = p.UpTo(ctx, 5)
+	require.NoError(t, err, "p.UpTo(...) error")
+	require.Int(t, appliedCount, 1, "p.UpTo(...) applied count")
+
+	err = p.UpByOne(ctx)
+	require.ErrorIs(t, err, migratory.ErrNoPendingMigrations, "p.UpByOne(...) error")
+
+	rolledBackCount, err := p.DownTo(ctx, 4)
+	require.NoError(t, err, "p.DownTo(...) error")
+	require.Int(t, rolledBackCount, 1, "p.DownTo(...) rolled back count")
+
+	rolledBackCount, err = p.Reset(ctx)
+	require.NoError(t, err, "p.Reset(...) error")
+	require.Int(t, rolledBackCount, 1, "p.Reset(...) rolled back count")
+
+	_, err = p.Version(ctx)
+	require.ErrorIs(t, err, migrator.ErrUnknownDBVersion, "p.Version(...) after p.Reset(...)")
+}
+
+// TestSQLiteChecksums checks that SQL migrations modified after being applied are detected,
+// and that a migrations table created without the checksum column is upgraded.
+func TestSQLiteChecksums(t *testing.T) {
+	db := setupSQLiteDB(t)
+	ctx := context.Background()
+
+	_, err := db.Exec(
+		"CREATE TABLE migrations (id INTEGER PRIMARY KEY, name TEXT NOT NULL, applied_at TIMESTAMP NOT NULL)")
+	require.NoError(t, err, "db.Exec(...) create old migrations table error")
+
+	fsys := fstest.MapFS{
+		"migrations/1_create_table_a.sql": {
+			Data: []byte("-- +migrate up\nCREATE TABLE a (id INTEGER);\n-- +migrate down\nDROP TABLE a;\n"),
+		},
+	}
+	p, err := migratory.NewProvider(db, migratory.SQLite,
+		migratory.WithSQLMigrationFS(fsys, "migrations"), migratory.WithChecksumValidation())
+	require.NoError(t, err, "migratory.NewProvider(...) error")
+
+	_, err = p.Up(ctx)
+	require.NoError(t, err, "p.Up(...) error")
+	require.NoError(t, p.Validate(ctx), "p.Validate(...) of unmodified migrations")
+
+	fsys["migrations/1_create_table_a.sql"].Data = []byte(
+		"-- +migrate up\nCREATE TABLE a (id INTEGER, title TEXT);\n-- +migrate down\nDROP TABLE a;\n")
+	fsys["migrations/2_create_table_b.sql"] = &fstest.MapFile{
+		Data: []byte("-- +migrate up\nCREATE TABLE b (id INTEGER);\n-- +migrate down\nDROP TABLE b;\n"),
+	}
+
+	results, err := p.Status(ctx)
+	require.NoError(t, err, "p.Status(...) error")
+	require.Bool(t, results[0].Modified, true, "p.Status(...) modified migration")
+	require.Bool(t, results[1].Modified, false, "p.Status(...) pending migration")
+
+	err = p.Validate(ctx)
+	require.ErrorIs(t, err, migratory.ErrModifiedMigrations, "p.Validate(...) error")
+
+	appliedCount, err := p.Up(ctx)
+	require.ErrorIs(t, err, migratory.ErrModifiedMigrations, "p.Up(...) with modified migrations error")
+	require.Int(t, appliedCount, 0, "p.Up(...) with modified migrations applied count")
+	require.Bool(t, sqliteTableExists(t, db, "b"), false, "table b must not be created")
+}
+
+// TestSQLitePreflight checks that invalid pending migrations are reported at once before any is applied.
+func TestSQLitePreflight(t *testing.T) {
+	db := setupSQLiteDB(t)
+	ctx := context.Background()
+
+	fsys := fstest.MapFS{
+		"migrations/1_create_table_a.sql": {
+			Data: []byte("-- +migrate up\nCREATE TABLE a (id INTEGER);\n-- +migrate down\nDROP TABLE a;\n"),
+		},
+		"migrations/2_create_table_b.sql": {
+			Data: []byte("-- +migrate up\nCREATE TABLE b (id INTEGER);\n-- +migrate statment_end\n"),
+		},
+		"migrations/3_create_table_c.sql": {
+			Data: []byte("-- +migrate up\nCREATE TABLE c (id INTEGER)\n"),
+		},
+	}
+	p, err := migratory.NewProvider(db, migratory.SQLite,
+		migratory.WithSQLMigrationFS(fsys, "migrations"), migratory.WithPreflight())
+	require.NoError(t, err, "migratory.NewProvider(...) error")
+
+	appliedCount, err := p.Up(ctx)
+	require.ErrorIs(t, err, migratory.ErrInvalidMigrations, "p.Up(...) with invalid migrations error")
+	require.Int(t, appliedCount, 0, "p.Up(...) with invalid migrations applied count")
+	require.Bool(t, sqliteTableExists(t, db, "a"), false, "table a must not be created")
+
+	var parseErr *migratory.ParseError
+	require.Bool(t, errors.As(err, &parseErr), true, "p.Up(...) error must contain a ParseError")
+	require.String(t, parseErr.File, "migrations/2_create_table_b.sql", "ParseError file")
+	require.Int(t, parseErr.Line, 3, "ParseError line")
+
+	err = p.Validate(ctx)
+	require.ErrorIs(t, err, migratory.ErrInvalidMigrations, "p.Validate(...) error")
+	for _, file := range []string{"2_create_table_b.sql:3:1", "3_create_table_c.sql:2:1"} {
+		require.Bool(t, strings.Contains(err.Error(), file), true, "p.Validate(...) error must report "+file)
+	}
+}
+
+// TestValidateFiles checks that migration files are validated without a database.
+func TestValidateFiles(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		fsys      fstest.MapFS
+		wantErr   error
+		wantFiles []string
+	}{
+		"valid": {
+			fsys: fstest.MapFS{
+				"migrations/1_create_table_a.sql": {
+					Data: []byte("-- +migrate up\nCREATE TABLE a (id INTEGER);\n-- +migrate down\nDROP TABLE a;\n"),
+				},
+			},
+		},
+		"invalid": {
+			fsys: fstest.MapFS{
+				"migrations/1_create_table_a.sql": {
+					Data: []byte("-- +migrate up\nCREATE TABLE a (id INTEGER)\n"),
+				},
+				"migrations/R_views.sql": {
+					Data: []byte("-- +migrate up\nCREATE VIEW v AS SELECT 1;\n-- +migrate statment_end\n"),
+				},
+			},
+			wantErr:   migratory.ErrInvalidMigrations,
+			wantFiles: []string{"1_create_table_a.sql:2:1", "R_views.sql:3:1"},
+		},
+		"invalid callbacks": {
+			fsys: fstest.MapFS{
+				"migrations/1_create_table_a.sql": {
+					Data: []byte("-- +migrate up\nCREATE TABLE a (id INTEGER);\n-- +migrate down\nDROP TABLE a;\n"),
+				},
+				"migrations/" + migrator.CallbackBeforeAll: {
+					Data: []byte("-- +migrate up\nSELECT 'a;\n"),
+				},
+				"migrations/" + migrator.CallbackAfterEach: {
+					Data: []byte("-- +migrate up\nSELECT 1\n"),
+				},
+			},
+			wantErr:   migratory.ErrInvalidMigrations,
+			wantFiles: []string{migrator.CallbackBeforeAll + ":2:8", migrator.CallbackAfterEach + ":2:1"},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			err := migratory.ValidateFiles(migratory.WithDialect(migratory.SQLite),
+				migratory.WithSQLMigrationFS(test.fsys, "migrations"))
+			require.ErrorIs(t, err, test.wantErr, "migratory.ValidateFiles(...) error")
+			for _, file := range test.wantFiles {
+				require.Bool(t, strings.Contains(err.Error(), file), true, "migratory.ValidateFiles(...) must report "+file)
+			}
+		})
+	}
+}
+
+// TestSQLiteDryRun checks that a dry run plans migrations without running them.
+func TestSQLiteDryRun(t *testing.T) {
+	db := setupSQLiteDB(t)
+	ctx := context.Background()
+
+	fsys := fstest.MapFS{
+		"migrations/1_create_table_a.sql": {
+			Data: []byte("-- +migrate up\nCREATE TABLE a (id INTEGER);\nINSERT INTO a VALUES (1);\n" +
+				"-- +migrate down\nDROP TABLE a;\n"),
+		},
+		"migrations/2_create_table_b.sql": {
+			Data: []byte("-- +migrate up no_transaction\nCREATE TABLE b (id INTEGER);\n" +
+				"-- +migrate down no_transaction\nDROP TABLE b;\n"),
+		},
+	}
+	plan := &migratory.Plan{}
+	dryRun, err := migratory.NewProvider(db, migratory.SQLite,
+		migratory.WithSQLMigrationFS(fsys, "migrations"), migratory.WithDryRun(plan))
+	require.NoError(t, err, "migratory.NewProvider(...) dry run error")
+
+	plannedCount, err := dryRun.Up(ctx)
+	require.NoError(t, err, "dryRun.Up(...) error")
+	require.Int(t, plannedCount, 2, "dryRun.Up(...) planned count")
+	require.Equal(t, plan.Migrations, []migratory.PlannedMigration{
+		{
+			ID: 1, Name: "create_table_a", Direction: "up",
+			Statements: []string{"CREATE TABLE a (id INTEGER);\n", "INSERT INTO a VALUES (1);\n"},
+		},
+		{ID: 2, Name: "create_table_b", Direction: "up", NoTx: true, Statements: []string{"CREATE TABLE b (id INTEGER);\n"}},
+	}, "dryRun.Up(...) plan")
+	require.Bool(t, sqliteTableExists(t, db, "a"), false, "table a must not be created by dry run")
+	for _, table := range []string{"migrations", "migrations_dirty", "migrations_repeatable"} {
+		require.Bool(t, sqliteTableExists(t, db, table), false, "table "+table+" must not be created by dry run")
+	}
+	_, err = dryRun.Version(ctx)
+	require.ErrorIs(t, err, migrator.ErrUnknownDBVersion, "dryRun.Version(...) after dry run")
+
+	p, err := migratory.NewProvider(db, migratory.SQLite, migratory.WithSQLMigrationFS(fsys, "migrations"))
+	require.NoError(t, err, "migratory.NewProvider(...) error")
+	_, err = p.Up(ctx)
+	require.NoError(t, err, "p.Up(...) error")
+
+	plan.Migrations = nil
+	plannedCount, err = dryRun.DownTo(ctx, 0)
+	require.NoError(t, err, "dryRun.DownTo(...) error")
+	require.Int(t, plannedCount, 2, "dryRun.DownTo(...) planned count")
+	require.Int64(t, plan.Migrations[0].ID, 2, "dryRun.DownTo(...) first planned migration")
+	require.Int64(t, plan.Migrations[1].ID, 1, "dryRun.DownTo(...) second planned migration")
+	require.Equal(t, plan.Migrations[1].Statements, []string{"DROP TABLE a;\n"}, "dryRun.DownTo(...) statements")
+
+	plan.Migrations = nil
+	require.NoError(t, dryRun.Redo(ctx), "dryRun.Redo(...) error")
+	require.Int(t, len(plan.Migrations), 2, "dryRun.Redo(...) planned migrations")
+	require.String(t, plan.Migrations[0].Direction, "down", "dryRun.Redo(...) first direction")
+	require.String(t, plan.Migrations[1].Direction, "up", "dryRun.Redo(...) second direction")
+
+	require.Bool(t, sqliteTableExists(t, db, "b"), true, "table b must not be dropped by dry run")
+	version, err := dryRun.Version(ctx)
+	require.NoError(t, err, "dryRun.Version(...) error")
+	require.Int64(t, version, 2, "dryRun.Version(...) after dry run")
+}
+
+// TestSQLiteDryRunExistingTables checks that a dry run plans against the tables as they are:
+// a migrations table created by an earlier version is not upgraded, and an interrupted migration
+// is planned with the statements left to execute.
+func TestSQLiteDryRunExistingTables(t *testing.T) {
+	t.Parallel()
+	db := setupSQLiteDB(t)
+	ctx := context.Background()
+
+	_, err := db.Exec("CREATE TABLE migrations (id INTEGER PRIMARY KEY, name TEXT NOT NULL, " +
+		"applied_at TIMESTAMP NOT NULL)")
+	require.NoError(t, err, "db.Exec(...) create legacy migrations table error")
+	_, err = db.Exec("INSERT INTO migrations (id, name, applied_at) VALUES (1, 'create_table_a', CURRENT_TIMESTAMP)")
+	require.NoError(t, err, "db.Exec(...) insert applied migration error")
+
+	fsys := fstest.MapFS{
+		"migrations/1_create_table_a.sql": {
+			Data: []byte("-- +migrate up\nCREATE TABLE a (id INTEGER);\n-- +migrate down\nDROP TABLE a;\n"),
+		},
+		"migrations/2_create_tables_notx.sql": {
+			Data: []byte("-- +migrate up no_transaction\nCREATE TABLE b (id INTEGER);\nCREATE TABLE b (id INTEGER);\n" +
+				"CREATE TABLE c (id INTEGER);\n-- +migrate down no_transaction\nDROP TABLE c;\nDROP TABLE b;\n"),
+		},
+	}
+	plan := &migratory.Plan{}
+	dryRun, err := migratory.NewProvider(db, migratory.SQLite,
+		migratory.WithSQLMigrationFS(fsys, "migrations"), migratory.WithDryRun(plan))
+	require.NoError(t, err, "migratory.NewProvider(...) dry run error")
+
+	_, err = dryRun.Up(ctx)
+	require.NoError(t, err, "dryRun.Up(...) against legacy migrations table error")
+	require.Int(t, len(plan.Migrations), 1, "dryRun.Up(...) planned migrations")
+	require.Int64(t, plan.Migrations[0].ID, 2, "dryRun.Up(...) planned migration")
+	rows, err := db.Query("SELECT * FROM migrations LIMIT 0")
+	require.NoError(t, err, "db.Query(...) migrations table columns error")
+	columns, err := rows.Columns()
+	require.NoError(t, err, "rows.Columns() error")
+	require.NoError(t, rows.Close(), "rows.Close() error")
+	require.Int(t, len(columns), 3, "migrations table must not be upgraded by dry run")
+
+	p, err := migratory.NewProvider(db, migratory.SQLite, migratory.WithSQLMigrationFS(fsys, "migrations"))
+	require.NoError(t, err, "migratory.NewProvider(...) error")
+	_, err = p.Up(ctx)
+	require.Error(t, err, "p.Up(...) of failing migration must fail")
+
+	fsys["migrations/2_create_tables_notx.sql"].Data = []byte("-- +migrate up no_transaction\n" +
+		"CREATE TABLE b (id INTEGER);\nCREATE INDEX b_id ON b (id);\nCREATE TABLE c (id INTEGER);\n" +
+		"-- +migrate down no_transaction\nDROP TABLE c;\nDROP TABLE b;\n")
+	plan.Migrations = nil
+	_, err = dryRun.Up(ctx)
+	require.NoError(t, err, "dryRun.Up(...) of interrupted migration error")
+	require.Int(t, len(plan.Migrations), 1, "dryRun.Up(...) planned migrations")
+	require.Equal(t, plan.Migrations[0].Statements, []string{"CREATE INDEX b_id ON b (id);\n",
+		"CREATE TABLE c (id INTEGER);\n"}, "dryRun.Up(...) must plan the statements left to execute")
+}
+
+// TestSQLiteBaseline checks that baselined migrations are recorded as applied without running them.
+func TestSQLiteBaseline(t *testing.T) {
+	db := setupSQLiteDB(t)
+	ctx := context.Background()
+
+	fsys := fstest.MapFS{
+		"migrations/1_create_table_a.sql": {
+			Data: []byte("-- +migrate up\nCREATE TABLE a (id INTEGER);\n-- +migrate down\nDROP TABLE a;\n"),
+		},
+		"migrations/2_create_table_b.sql": {
+			Data: []byte("-- +migrate up\nCREATE TABLE b (id INTEGER);\n-- +migrate down\nDROP TABLE b;\n"),
+		},
+		"migrations/3_create_table_c.sql": {
+			Data: []byte("-- +migrate up\nCREATE TABLE c (id INTEGER);\n-- +migrate down\nDROP TABLE c;\n"),
+		},
+	}
+	p, err := migratory.NewProvider(db, migratory.SQLite, migratory.WithSQLMigrationFS(fsys, "migrations"))
+	require.NoError(t, err, "migratory.NewProvider(...) error")
+
+	baselinedCount, err := p.Baseline(ctx, 2, false)
+	require.NoError(t, err, "p.Baseline(...) error")
+	require.Int(t, baselinedCount, 2, "p.Baseline(...) count")
+	require.Bool(t, sqliteTableExists(t, db, "a"), false, "table a must not be created by baseline")
+
+	_, err = p.Baseline(ctx, 3, false)
+	require.ErrorIs(t, err, migratory.ErrNotEmptyTable, "p.Baseline(...) of not empty table error")
+
+	appliedCount, err := p.Up(ctx)
+	require.NoError(t, err, "p.Up(...) error")
+	require.Int(t, appliedCount, 1, "p.Up(...) applied count after baseline")
+	require.Bool(t, sqliteTableExists(t, db, "c"), true, "table c must be created")
+
+	results, err := p.Status(ctx)
+	require.NoError(t, err, "p.Status(...) error")
+	for i, want := range []bool{true, true, false} {
+		require.Bool(t, results[i].IsApplied, true, fmt.Sprintf("results[%d].IsApplied", i))
+		require.Bool(t, results[i].Baselined, want, fmt.Sprintf("results[%d].Baselined", i))
+	}
+	require.NoError(t, p.Validate(ctx), "p.Validate(...) of baselined migrations")
+
+	fsys["migrations/4_create_table_d.sql"] = &fstest.MapFile{
+		Data: []byte("-- +migrate up\nCREATE TABLE d (id INTEGER);\n-- +migrate down\nDROP TABLE d;\n"),
+	}
+	baselinedCount, err = p.Baseline(ctx, 4, true)
+	require.NoError(t, err, "p.Baseline(...) forced error")
+	require.Int(t, baselinedCount, 1, "p.Baseline(...) forced count")
+	require.Bool(t, sqliteTableExists(t, db, "d"), false, "table d must not be created by baseline")
+}
+
+// TestSQLiteDryRunBaseline checks that a dry run of baseline returns the number of migrations it would mark
+// without changing the database.
+func TestSQLiteDryRunBaseline(t *testing.T) {
+	db := setupSQLiteDB(t)
+	ctx := context.Background()
+
+	fsys := fstest.MapFS{
+		"migrations/1_create_table_a.sql": {
+			Data: []byte("-- +migrate up\nCREATE TABLE a (id INTEGER);\n-- +migrate down\nDROP TABLE a;\n"),
+		},
+		"migrations/2_create_table_b.sql": {
+			Data: []byte("-- +migrate up\nCREATE TABLE b (id INTEGER);\n-- +migrate down\nDROP TABLE b;\n"),
+		},
+	}
+	dryRun, err := migratory.NewProvider(db, migratory.SQLite,
+		migratory.WithSQLMigrationFS(fsys, "migrations"), migratory.WithDryRun(&migratory.Plan{}))
+	require.NoError(t, err, "migratory.NewProvider(...) dry run error")
+
+	baselinedCount, err := dryRun.Baseline(ctx, 2, false)
+	require.NoError(t, err, "dryRun.Baseline(...) of new database error")
+	require.Int(t, baselinedCount, 2, "dryRun.Baseline(...) of new database count")
+	require.Bool(t, sqliteTableExists(t, db, "migrations"), false, "migrations table must not be created by dry run")
+
+	p, err := migratory.NewProvider(db, migratory.SQLite, migratory.WithSQLMigrationFS(fsys, "migrations"))
+	require.NoError(t, err, "migratory.NewProvider(...) error")
+	_, err = p.UpTo(ctx, 1)
+	require.NoError(t, err, "p.UpTo(...) error")
+
+	_, err = dryRun.Baseline(ctx, 2, false)
+	require.ErrorIs(t, err, migratory.ErrNotEmptyTable, "dryRun.Baseline(...) of not empty table error")
+	baselinedCount, err = dryRun.Baseline(ctx, 2, true)
+	require.NoError(t, err, "dryRun.Baseline(...) forced error")
+	require.Int(t, baselinedCount, 1, "dryRun.Baseline(...) forced count")
+
+	version, err := p.Version(ctx)
+	require.NoError(t, err, "p.Version(...) error")
+	require.Int64(t, version, 1, "p.Version(...) after dry run of baseline")
+}
+
+// TestSQLiteDryRunMark checks that dry runs of mark and repair return the migration they would record
+// without changing the database.
+func TestSQLiteDryRunMark(t *testing.T) {
+	db := setupSQLiteDB(t)
+	ctx := context.Background()
+
+	fsys := fstest.MapFS{
+		"migrations/1_create_table_a.sql": {
+			Data: []byte("-- +migrate up\nCREATE TABLE a (id INTEGER);\n-- +migrate down\nDROP TABLE a;\n"),
+		},
+		"migrations/2_create_table_b_notx.sql": {
+			Data: []byte("-- +migrate up no_transaction\nCREATE TABLE b (id INTEGER);\nCREATE TABLE b (id INTEGER);\n" +
+				"-- +migrate down no_transaction\nDROP TABLE b;\n"),
+		},
+	}
+	dryRun, err := migratory.NewProvider(db, migratory.SQLite,
+		migratory.WithSQLMigrationFS(fsys, "migrations"), migratory.WithDryRun(&migratory.Plan{}))
+	require.NoError(t, err, "migratory.NewProvider(...) dry run error")
+
+	marked, err := dryRun.MarkApplied(ctx, 1)
+	require.NoError(t, err, "dryRun.MarkApplied(...) of new database error")
+	require.Int64(t, marked.ID, 1, "dryRun.MarkApplied(...) migration ID")
+	require.Bool(t, sqliteTableExists(t, db, "migrations"), false, "migrations table must not be created by dry run")
+	_, err = dryRun.Repair(ctx, migratory.RepairApplied)
+	require.ErrorIs(t, err, migratory.ErrNotDirty, "dryRun.Repair(...) of new database error")
+
+	p, err := migratory.NewProvider(db, migratory.SQLite, migratory.WithSQLMigrationFS(fsys, "migrations"))
+	require.NoError(t, err, "migratory.NewProvider(...) error")
+	_, err = p.Up(ctx)
+	require.Error(t, err, "p.Up(...) of failing migration must fail")
+
+	_, err = dryRun.MarkApplied(ctx, 1)
+	require.ErrorIs(t, err, migratory.ErrAlreadyApplied, "dryRun.MarkApplied(...) of applied migration error")
+	marked, err = dryRun.MarkUnapplied(ctx, 1)
+	require.NoError(t, err, "dryRun.MarkUnapplied(...) error")
+	require.String(t, marked.Name, "create_table_a", "dryRun.MarkUnapplied(...) migration name")
+	repaired, err := dryRun.Repair(ctx, migratory.RepairRolledBack)
+	require.NoError(t, err, "dryRun.Repair(...) error")
+	require.Int64(t, repaired.ID, 2, "dryRun.Repair(...) migration ID")
+
+	results, err := p.Status(ctx)
+	require.NoError(t, err, "p.Status(...) error")
+	require.Bool(t, results[0].IsApplied, true, "p.Status(...) migration must stay applied after dry run")
+	require.Bool(t, results[1].Dirty, true, "p.Status(...) migration must stay dirty after dry run")
+}
+
+// TestSQLiteLogger checks that migration runs and their statements are logged.
+func TestSQLiteLogger(t *testing.T) {
+	db := setupSQLiteDB(t)
+	ctx := context.Background()
+
+	fsys := fstest.MapFS{
+		"migrations/1_create_table_a.sql": {
+			Data: []byte("-- +migrate up\nCREATE TABLE a (id INTEGER);\n-- +migrate down\nDROP TABLE a;\n"),
+		},
+	}
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	p, err := migratory.NewProvider(db, migratory.SQLite,
+		migratory.WithSQLMigrationFS(fsys, "migrations"), migratory.WithLogger(logger))
+	require.NoError(t, err, "migratory.NewProvider(...) error")
+
+	_, err = p.Up(ctx)
+	require.NoError(t, err, "p.Up(...) error")
+	require.NoError(t, p.Down(ctx), "p.Down(...) error")
+
+	var logged []string
+	decoder := json.NewDecoder(&buf)
+	for decoder.More() {
+		var record struct {
+			Msg       string `json:"msg"`
+			ID        int64  `json:"id"`
+			Direction string `json:"direction"`
+			Statement string `json:"statement"`
+		}
+		require.NoError(t, decoder.Decode(&record), "decoder.Decode(...) error")
+		logged = append(logged, strings.TrimSpace(fmt.Sprintf("%s %d %s %s",
+			record.Msg, record.ID, record.Direction, record.Statement)))
+	}
+
+	require.Equal(t, logged, []string{
+		"created migrations table 0",
+		"migration started 1 up",
+		"executing statement 1 up CREATE TABLE a (id INTEGER);",
+		"migration finished 1 up",
+		"migration started 1 down",
+		"executing statement 1 down DROP TABLE a;",
+		"migration finished 1 down",
+	}, "logged records")
+}
+
+// TestSQLiteHooks checks that hooks are called around migration runs and a BeforeEach error aborts the run.
+func TestSQLiteHooks(t *testing.T) {
+	db := setupSQLiteDB(t)
+	ctx := context.Background()
+
+	fsys := fstest.MapFS{
+		"migrations/1_create_table_a.sql": {
+			Data: []byte("-- +migrate up\nCREATE TABLE a (id INTEGER);\n-- +migrate down\nDROP TABLE a;\n"),
+		},
+		"migrations/2_create_table_b.sql": {
+			Data: []byte("-- +migrate up no_transaction\nCREATE TABLE b (id INTEGER);\n" +
+				"-- +migrate down no_transaction\nDROP TABLE b;\n"),
+		},
+	}
+
+	var called []string
+	record := func(hook string) migratory.HookFn {
+		return func(_ context.Context, info migratory.HookInfo) error {
+			called = append(called, fmt.Sprintf("%s %s %d tx=%t", hook, info.Direction, info.ID, info.Tx != nil))
+			return nil
+		}
+	}
+	hooks := migratory.Hooks{
+		BeforeAll:  record("before all"),
+		AfterAll:   record("after all"),
+		BeforeEach: record("before each"),
+		AfterEach:  record("after each"),
+	}
+	p, err := migratory.NewProvider(db, migratory.SQLite,
+		migratory.WithSQLMigrationFS(fsys, "migrations"), migratory.WithHooks(hooks))
+	require.NoError(t, err, "migratory.NewProvider(...) error")
+
+	_, err = p.Up(ctx)
+	require.NoError(t, err, "p.Up(...) error")
+	require.NoError(t, p.Down(ctx), "p.Down(...) error")
+	_, err = p.Up(ctx)
+	require.NoError(t, err, "p.Up(...) after p.Down(...) error")
+	_, err = p.Up(ctx)
+	require.NoError(t, err, "p.Up(...) of nothing to apply error")
+
+	require.Equal(t, called, []string{
+		"before all up 0 tx=false",
+		"before each up 1 tx=true",
+		"after each up 1 tx=true",
+		"before each up 2 tx=false",
+		"after each up 2 tx=false",
+		"after all up 0 tx=false",
+		"before all down 0 tx=false",
+		"before each down 2 tx=false",
+		"after each down 2 tx=false",
+		"after all down 0 tx=false",
+		"before all up 0 tx=false",
+		"before each up 2 tx=false",
+		"after each up 2 tx=false",
+		"after all up 0 tx=false",
+	}, "called hooks")
+
+	errHook := errors.New("hook error")
+	var failed []int64
+	hooks = migratory.Hooks{
+		BeforeEach: func(context.Context, migratory.HookInfo) error { return errHook },
+		OnError: func(_ context.Context, info migratory.HookInfo, err error) {
+			require.ErrorIs(t, err, errHook, "OnError(...) error")
+			failed = append(failed, info.ID)
+		},
+	}
+	p, err = migratory.NewProvider(db, migratory.SQLite,
+		migratory.WithSQLMigrationFS(fsys, "migrations"), migratory.WithHooks(hooks))
+	require.NoError(t, err, "migratory.NewProvider(...) error")
+
+	_, err = p.DownTo(ctx, 0)
+	require.ErrorIs(t, err, errHook, "p.DownTo(...) error")
+	require.Equal(t, failed, []int64{2}, "failed migrations")
+	require.Bool(t, sqliteTableExists(t, db, "b"), true, "table b must not be dropped")
+}
+
+// TestSQLiteCallbacks checks that SQL callback files are run around migrations in the direction of the run.
+func TestSQLiteCallbacks(t *testing.T) {
+	db := setupSQLiteDB(t)
+	ctx := context.Background()
+
+	fsys := fstest.MapFS{
+		"migrations/1_create_table_a.sql": {
+			Data: []byte("-- +migrate up\nCREATE TABLE a (id INTEGER);\n-- +migrate down\nDROP TABLE a;\n"),
+		},
+		"migrations/2_create_table_b.sql": {
+			Data: []byte("-- +migrate up\nCREATE TABLE b (id INTEGER);\n-- +migrate down\nDROP TABLE b;\n"),
+		},
+		"migrations/" + migrator.CallbackBeforeAll: {
+			Data: []byte("-- +migrate up\nCREATE TABLE IF NOT EXISTS events (name TEXT);\n" +
+				"INSERT INTO events VALUES ('before all up');\n" +
+				"-- +migrate down\nINSERT INTO events VALUES ('before all down');\n"),
+		},
+		"migrations/" + migrator.CallbackAfterEach: {
+			Data: []byte("-- +migrate up\nINSERT INTO events VALUES ('after each up');\n"),
+		},
+	}
+	p, err := migratory.NewProvider(db, migratory.SQLite, migratory.WithSQLMigrationFS(fsys, "migrations"))
+	require.NoError(t, err, "migratory.NewProvider(...) error")
+
+	appliedCount, err := p.Up(ctx)
+	require.NoError(t, err, "p.Up(...) error")
+	require.Int(t, appliedCount, 2, "p.Up(...) applied count")
+	require.NoError(t, p.Down(ctx), "p.Down(...) error")
+
+	rows, err := db.QueryContext(ctx, "SELECT name FROM events ORDER BY rowid")
+	require.NoError(t, err, "select events error")
+	defer rows.Close()
+
+	var events []string
+	for rows.Next() {
+		var event string
+		require.NoError(t, rows.Scan(&event), "rows.Scan(...) error")
+		events = append(events, event)
+	}
+	require.NoError(t, rows.Err(), "rows.Err() error")
+	require.Equal(t, events, []string{"before all up", "after each up", "after each up", "before all down"},
+		"events recorded by callbacks")
+}
+
+// TestSQLiteInvalidCallback checks that an invalid callback file fails runs of migrations before any is run,
+// but not the commands that don't run migrations.
+func TestSQLiteInvalidCallback(t *testing.T) {
+	db := setupSQLiteDB(t)
+	ctx := context.Background()
+
+	fsys := fstest.MapFS{
+		"migrations/1_create_table_a.sql": {
+			Data: []byte("-- +migrate up\nCREATE TABLE a (id INTEGER);\n-- +migrate down\nDROP TABLE a;\n"),
+		},
+		"migrations/" + migrator.CallbackAfterEach: {
+			Data: []byte("-- +migrate up\nSELECT 1\n"),
+		},
+	}
+	p, err := migratory.NewProvider(db, migratory.SQLite, migratory.WithSQLMigrationFS(fsys, "migrations"))
+	require.NoError(t, err, "migratory.NewProvider(...) error")
+
+	results, err := p.Status(ctx)
+	require.NoError(t, err, "p.Status(...) error")
+	require.Int(t, len(results), 1, "p.Status(...) results")
+	_, err = p.Version(ctx)
+	require.ErrorIs(t, err, migrator.ErrUnknownDBVersion, "p.Version(...) error")
+
+	_, err = p.Up(ctx)
+	require.ErrorIs(t, err, migratory.ErrInvalidMigrations, "p.Up(...) error")
+	require.Bool(t, sqliteTableExists(t, db, "a"), false, "table a must not be created")
+	require.ErrorIs(t, p.Validate(ctx), migratory.ErrInvalidMigrations, "p.Validate(...) error")
+}
+
+// TestSQLiteRepeatable checks that repeatable migrations are applied after versioned ones when they change.
+func TestSQLiteRepeatable(t *testing.T) {
+	db := setupSQLiteDB(t)
+	ctx := context.Background()
+
+	fsys := fstest.MapFS{
+		"migrations/1_create_table_a.sql": {
+			Data: []byte("-- +migrate up\nCREATE TABLE a (id INTEGER);\n-- +migrate down\nDROP TABLE a;\n"),
+		},
+		"migrations/R_view_a.sql": {
+			Data: []byte("-- +migrate up\nDROP VIEW IF EXISTS view_a;\nCREATE VIEW view_a AS SELECT id FROM a;\n"),
+		},
+	}
+	p, err := migratory.NewProvider(db, migratory.SQLite, migratory.WithSQLMigrationFS(fsys, "migrations"))
+	require.NoError(t, err, "migratory.NewProvider(...) error")
+
+	appliedCount, err := p.Up(ctx)
+	require.NoError(t, err, "p.Up(...) error")
+	require.Int(t, appliedCount, 2, "p.Up(...) applied count")
+	var count int
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT count(*) FROM view_a").Scan(&count), "select from view_a")
+
+	appliedCount, err = p.Up(ctx)
+	require.NoError(t, err, "p.Up(...) of unchanged repeatable migration error")
+	require.Int(t, appliedCount, 0, "p.Up(...) of unchanged repeatable migration applied count")
+
+	fsys["migrations/R_view_a.sql"] = &fstest.MapFile{
+		Data: []byte("-- +migrate up\nDROP VIEW IF EXISTS view_a;\nCREATE VIEW view_a AS SELECT id, 1 AS one FROM a;\n"),
+	}
+	appliedCount, err = p.UpTo(ctx, 1)
+	require.NoError(t, err, "p.UpTo(...) error")
+	require.Int(t, appliedCount, 0, "p.UpTo(...) must not apply repeatable migrations")
+
+	appliedCount, err = p.Up(ctx)
+	require.NoError(t, err, "p.Up(...) of changed repeatable migration error")
+	require.Int(t, appliedCount, 1, "p.Up(...) of changed repeatable migration applied count")
+
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT count(one) FROM view_a").Scan(&count), "select from changed view_a")
+}
+
+// TestSQLiteSplitFiles checks migrations with up and down statements in separate .up.sql and .down.sql files,
+// and that a migration without down file is not rolled back.
+func TestSQLiteSplitFiles(t *testing.T) {
+	db := setupSQLiteDB(t)
+	ctx := context.Background()
+
+	fsys := fstest.MapFS{
+		"migrations/000001_create_table_a.up.sql":   {Data: []byte("CREATE TABLE a (id INTEGER);\n")},
+		"migrations/000001_create_table_a.down.sql": {Data: []byte("DROP TABLE a;\n")},
+		"migrations/000002_create_table_b.up.sql":   {Data: []byte("CREATE TABLE b (id INTEGER);\n")},
+		"migrations/000003_create_table_c.up.sql": {
+			Data: []byte("-- +migrate no_transaction\nCREATE TABLE c (id INTEGER);\n"),
+		},
+		"migrations/000003_create_table_c.down.sql": {Data: []byte("-- +migrate no_transaction\nDROP TABLE c;\n")},
+	}
+	p, err := migratory.NewProvider(db, migratory.SQLite, migratory.WithSQLMigrationFS(fsys, "migrations"))
+	require.NoError(t, err, "migratory.NewProvider(...) error")
+
+	appliedCount, err := p.Up(ctx)
+	require.NoError(t, err, "p.Up(...) error")
+	require.Int(t, appliedCount, 3, "p.Up(...) applied count")
+	require.Bool(t, sqliteTableExists(t, db, "b"), true, "table b must be created")
+
+	rolledBackCount, err := p.Reset(ctx)
+	require.ErrorIs(t, err, migratory.ErrIrreversible, "p.Reset(...) error")
+	require.Int(t, rolledBackCount, 0, "p.Reset(...) rolled back count")
+	require.Bool(t, sqliteTableExists(t, db, "c"), true, "table c must not be dropped by p.Reset(...)")
+
+	require.NoError(t, p.Down(ctx), "p.Down(...) error")
+	require.Bool(t, sqliteTableExists(t, db, "c"), false, "table c must be dropped")
+
+	require.ErrorIs(t, p.Down(ctx), migratory.ErrIrreversible, "p.Down(...) of migration without down file error")
+	require.ErrorIs(t, p.Redo(ctx), migratory.ErrIrreversible, "p.Redo(...) of migration without down file error")
+	_, err = p.DownTo(ctx, 1)
+	require.ErrorIs(t, err, migratory.ErrIrreversible, "p.DownTo(...) error")
+
+	version, err := p.Version(ctx)
+	require.NoError(t, err, "p.Version(...) error")
+	require.Int64(t, version, 2, "p.Version(...) after rollbacks of migration without down file")
+	require.Bool(t, sqliteTableExists(t, db, "b"), true, "table b without down file must be kept")
+}
+
+// TestSQLiteMark checks that migrations are recorded as applied and not applied without running them.
+func TestSQLiteMark(t *testing.T) {
+	db := setupSQLiteDB(t)
+	ctx := context.Background()
+
+	fsys := fstest.MapFS{
+		"migrations/1_create_table_a.sql": {
+			Data: []byte("-- +migrate up\nCREATE TABLE a (id INTEGER);\n-- +migrate down\nDROP TABLE a;\n"),
+		},
+		"migrations/2_create_table_b.sql": {
+			Data: []byte("-- +migrate up\nCREATE TABLE b (id INTEGER);\n-- +migrate down\nDROP TABLE b;\n"),
+		},
+	}
+	p, err := migratory.NewProvider(db, migratory.SQLite, migratory.WithSQLMigrationFS(fsys, "migrations"))
+	require.NoError(t, err, "migratory.NewProvider(...) error")
+
+	_, err = p.MarkApplied(ctx, 3)
+	require.ErrorIs(t, err, migratory.ErrMigrationNotFound, "p.MarkApplied(...) of unknown migration error")
+	_, err = p.MarkUnapplied(ctx, 1)
+	require.ErrorIs(t, err, migratory.ErrNotApplied, "p.MarkUnapplied(...) of not applied migration error")
+
+	marked, err := p.MarkApplied(ctx, 2)
+	require.NoError(t, err, "p.MarkApplied(...) error")
+	require.String(t, marked.Name, "create_table_b", "p.MarkApplied(...) migration name")
+	_, err = p.MarkApplied(ctx, 2)
+	require.ErrorIs(t, err, migratory.ErrAlreadyApplied, "p.MarkApplied(...) of applied migration error")
+	require.NoError(t, p.Validate(ctx), "p.Validate(...) of marked migration")
+
+	appliedCount, err := p.Up(ctx)
+	require.ErrorIs(t, err, migrator.ErrDirtyMigrations, "p.Up(...) of migration before marked one error")
+	require.Int(t, appliedCount, 0, "p.Up(...) applied count")
+
+	_, err = p.MarkUnapplied(ctx, 2)
+	require.NoError(t, err, "p.MarkUnapplied(...) error")
+	appliedCount, err = p.Up(ctx)
+	require.NoError(t, err, "p.Up(...) error")
+	require.Int(t, appliedCount, 2, "p.Up(...) applied count after unmarking")
+	require.Bool(t, sqliteTableExists(t, db, "b"), true, "table b must be created")
+}
+
+// TestSQLiteImport checks that migrations applied by other migration tools are imported
+// with the time they were applied at.
+func TestSQLiteImport(t *testing.T) {
+	appliedAt := time.Date(2024, 1, 2, 10, 30, 0, 0, time.UTC)
+	tests := map[string]struct {
+		from       string
+		history    []string
+		wantIDs    []int64
+		keepsTimes bool
+	}{
+		"goose": {
+			from: migratory.ImportGoose,
+			history: []string{
+				`CREATE TABLE goose_db_version (id INTEGER PRIMARY KEY AUTOINCREMENT, version_id INTEGER NOT NULL,
+					is_applied INTEGER NOT NULL, tstamp TIMESTAMP DEFAULT (datetime('now')))`,
+				`INSERT INTO goose_db_version (version_id, is_applied) VALUES (0, 1)`,
+				`INSERT INTO goose_db_version (version_id, is_applied, tstamp) VALUES (1, 1, '2024-01-02 10:30:00')`,
+				`INSERT INTO goose_db_version (version_id, is_applied, tstamp) VALUES (2, 1, '2024-01-02 10:30:00')`,
+				`INSERT INTO goose_db_version (version_id, is_applied, tstamp) VALUES (2, 0, '2024-01-02 10:30:00')`,
+			},
+			wantIDs:    []int64{1},
+			keepsTimes: true,
+		},
+		"golang-migrate": {
+			from: migratory.ImportGolangMigrate,
+			history: []string{
+				`CREATE TABLE schema_migrations (version INTEGER NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)`,
+				`INSERT INTO schema_migrations (version, dirty) VALUES (2, 0)`,
+			},
+			wantIDs: []int64{1, 2},
+		},
+		"sql-migrate": {
+			from: migratory.ImportSQLMigrate,
+			history: []string{
+				`CREATE TABLE gorp_migrations (id TEXT NOT NULL PRIMARY KEY, applied_at DATETIME)`,
+				`INSERT INTO gorp_migrations (id, applied_at) VALUES ('1_create_table_a.sql', '2024-01-02 10:30:00')`,
+			},
+			wantIDs:    []int64{1},
+			keepsTimes: true,
+		},
+		"flyway": {
+			from: migratory.ImportFlyway,
+			history: []string{
+				`CREATE TABLE flyway_schema_history (installed_rank INT NOT NULL PRIMARY KEY, version VARCHAR(50),
+					type VARCHAR(20) NOT NULL, installed_on TEXT NOT NULL, success BOOLEAN NOT NULL)`,
+				`INSERT INTO flyway_schema_history VALUES (1, '1', 'BASELINE', '2024-01-02 10:30:00', 1)`,
+				`INSERT INTO flyway_schema_history VALUES (2, '2', 'SQL', '2024-01-02 10:30:00', 1)`,
+				`INSERT INTO flyway_schema_history VALUES (3, NULL, 'SQL', '2024-01-02 10:30:00', 1)`,
+			},
+			wantIDs:    []int64{1, 2},
+			keepsTimes: true,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			db := setupSQLiteDB(t)
+			ctx := context.Background()
+
+			for _, q := range test.history {
+				_, err := db.Exec(q)
+				require.NoError(t, err, "db.Exec(...) history error")
+			}
+
+			fsys := fstest.MapFS{
+				"migrations/1_create_table_a.sql": {
+					Data: []byte("-- +migrate up\nCREATE TABLE a (id INTEGER);\n-- +migrate down\nDROP TABLE a;\n"),
+				},
+				"migrations/2_create_table_b.sql": {
+					Data: []byte("-- +migrate up\nCREATE TABLE b (id INTEGER);\n-- +migrate down\nDROP TABLE b;\n"),
+				},
+				"migrations/3_create_table_c.sql": {
+					Data: []byte("-- +migrate up\nCREATE TABLE c (id INTEGER);\n-- +migrate down\nDROP TABLE c;\n"),
+				},
+			}
+			p, err := migratory.NewProvider(db, migratory.SQLite, migratory.WithSQLMigrationFS(fsys, "migrations"))
+			require.NoError(t, err, "migratory.NewProvider(...) error")
+
+			imported, err := p.Import(ctx, migratory.ImportOptions{From: test.from, DryRun: true})
+			require.NoError(t, err, "p.Import(...) dry run error")
+			require.Int(t, len(imported), len(test.wantIDs), "p.Import(...) dry run count")
+			version, err := p.Version(ctx)
+			require.ErrorIs(t, err, migrator.ErrUnknownDBVersion, "p.Version(...) after dry run error")
+			require.Int64(t, version, -1, "p.Version(...) after dry run, migrations must not be recorded")
+
+			imported, err = p.Import(ctx, migratory.ImportOptions{From: test.from})
+			require.NoError(t, err, "p.Import(...) error")
+			require.Int(t, len(imported), len(test.wantIDs), "p.Import(...) count")
+			for i, id := range test.wantIDs {
+				require.Int64(t, imported[i].ID, id, fmt.Sprintf("imported[%d].ID", i))
+			}
+
+			_, err = p.Import(ctx, migratory.ImportOptions{From: test.from})
+			require.ErrorIs(t, err, migratory.ErrNotEmptyTable, "p.Import(...) into not empty table error")
+			imported, err = p.Import(ctx, migratory.ImportOptions{From: test.from, Force: true})
+			require.NoError(t, err, "p.Import(...) forced error")
+			require.Int(t, len(imported), 0, "p.Import(...) forced count of migrations already recorded")
+
+			results, err := p.Status(ctx)
+			require.NoError(t, err, "p.Status(...) error")
+			for i, id := range test.wantIDs {
+				require.Bool(t, results[id-1].IsApplied, true, fmt.Sprintf("results[%d].IsApplied", id-1))
+				if test.keepsTimes {
+					require.Time(t, results[id-1].AppliedAt.UTC(), appliedAt, fmt.Sprintf("imported[%d].AppliedAt", i))
+				}
+			}
+			require.NoError(t, p.Validate(ctx), "p.Validate(...) of imported migrations")
+
+			appliedCount, err := p.Up(ctx)
+			require.NoError(t, err, "p.Up(...) error")
+			require.Int(t, appliedCount, 3-len(test.wantIDs), "p.Up(...) applied count after import")
+		})
+	}
+}
+
+// TestSQLiteDirtyRepair checks that an interrupted migration without transaction blocks further runs
+// until it's repaired.
+func TestSQLiteDirtyRepair(t *testing.T) {
+	db := setupSQLiteDB(t)
+	ctx := context.Background()
+
+	fsys := fstest.MapFS{
+		"migrations/1_create_tables_notx.sql": {
+			Data: []byte("-- +migrate up no_transaction\nCREATE TABLE a (id INTEGER);\nCREATE TABLE a (id INTEGER);\n" +
+				"-- +migrate down no_transaction\nDROP TABLE a;\n"),
+		},
+	}
+	p, err := migratory.NewProvider(db, migratory.SQLite, migratory.WithSQLMigrationFS(fsys, "migrations"))
+	require.NoError(t, err, "migratory.NewProvider(...) error")
+
+	_, err = p.Repair(ctx, migratory.RepairApplied)
+	require.ErrorIs(t, err, migratory.ErrNotDirty, "p.Repair(...) of clean database error")
+
+	_, err = p.Up(ctx)
+	require.Error(t, err, "p.Up(...) of failing migration must fail")
+
+	results, err := p.Status(ctx)
+	require.NoError(t, err, "p.Status(...) error")
+	require.Bool(t, results[0].Dirty, true, "p.Status(...) interrupted migration must be dirty")
+
+	// The migration is made transactional, so it can't be resumed from the failed statement.
+	fsys["migrations/1_create_tables_notx.sql"].Data = []byte(
+		"-- +migrate up\nCREATE TABLE a (id INTEGER);\nCREATE TABLE b (id INTEGER);\n" +
+			"-- +migrate down\nDROP TABLE b;\nDROP TABLE a;\n")
+
+	_, err = p.Up(ctx)
+	require.ErrorIs(t, err, migratory.ErrDirtyDatabase, "p.Up(...) of dirty database error")
+	err = p.Down(ctx)
+	require.ErrorIs(t, err, migratory.ErrDirtyDatabase, "p.Down(...) of dirty database error")
+
+	// The operator reverts the partly applied migration and fixes it.
+	_, err = db.Exec("DROP TABLE a")
+	require.NoError(t, err, "db.Exec(...) drop table error")
+	fsys["migrations/1_create_tables_notx.sql"].Data = []byte(
+		"-- +migrate up no_transaction\nCREATE TABLE a (id INTEGER);\nCREATE TABLE b (id INTEGER);\n" +
+			"-- +migrate down no_transaction\nDROP TABLE b;\nDROP TABLE a;\n")
+
+	repaired, err := p.Repair(ctx, migratory.RepairRolledBack)
+	require.NoError(t, err, "p.Repair(...) error")
+	require.Int64(t, repaired.ID, 1, "p.Repair(...) repaired migration ID")
+	require.Bool(t, repaired.IsApplied, false, "p.Repair(...) repaired migration must be rolled back")
+
+	appliedCount, err := p.Up(ctx)
+	require.NoError(t, err, "p.Up(...) after repair error")
+	require.Int(t, appliedCount, 1, "p.Up(...) after repair applied count")
+
+	err = p.Down(ctx)
+	require.NoError(t, err, "p.Down(...) error")
+	require.Bool(t, sqliteTableExists(t, db, "a"), false, "table a must be dropped")
+}
+
+// TestSQLiteResumeNoTx checks that a retried run of an interrupted SQL migration without transaction
+// continues from the statement that failed.
+func TestSQLiteResumeNoTx(t *testing.T) {
+	db := setupSQLiteDB(t)
+	ctx := context.Background()
+
+	fsys := fstest.MapFS{
+		"migrations/1_create_tables_notx.sql": {
+			Data: []byte("-- +migrate up no_transaction\nCREATE TABLE a (id INTEGER);\nCREATE TABLE a (id INTEGER);\n" +
+				"CREATE TABLE c (id INTEGER);\n-- +migrate down no_transaction\nDROP TABLE c;\nDROP TABLE a;\n"),
+		},
+	}
+	p, err := migratory.NewProvider(db, migratory.SQLite, migratory.WithSQLMigrationFS(fsys, "migrations"))
+	require.NoError(t, err, "migratory.NewProvider(...) error")
+
+	_, err = p.Up(ctx)
+	require.Error(t, err, "p.Up(...) of failing migration must fail")
+	require.Bool(t, sqliteTableExists(t, db, "a"), true, "table a must be created by the first statement")
+
+	// The failed statement is fixed, the executed one would fail if it was run again.
+	fsys["migrations/1_create_tables_notx.sql"].Data = []byte(
+		"-- +migrate up no_transaction\nCREATE TABLE a (id INTEGER);\nCREATE TABLE b (id INTEGER);\n" +
+			"CREATE TABLE c (id INTEGER);\n-- +migrate down no_transaction\nDROP TABLE c;\nDROP TABLE b;\nDROP TABLE a;\n")
+
+	appliedCount, err := p.Up(ctx)
+	require.NoError(t, err, "p.Up(...) resumed error")
+	require.Int(t, appliedCount, 1, "p.Up(...) resumed applied count")
+	for _, table := range []string{"a", "b", "c"} {
+		require.Bool(t, sqliteTableExists(t, db, table), true, "table "+table+" must be created")
+	}
+
+	results, err := p.Status(ctx)
+	require.NoError(t, err, "p.Status(...) error")
+	require.Bool(t, results[0].Dirty, false, "p.Status(...) resumed migration must not be dirty")
+}
+
+// TestSQLiteResumeRefused checks that an interrupted SQL migration without transaction is not resumed
+// if the statements executed by the interrupted run were edited or it's no longer run without transaction.
+func TestSQLiteResumeRefused(t *testing.T) {
+	tests := map[string]struct {
+		edited  string
+		wantErr error
+	}{
+		"executed statement edited": {
+			edited: "-- +migrate up no_transaction\nCREATE TABLE a2 (id INTEGER);\nCREATE TABLE b (id INTEGER);\n" +
+				"-- +migrate down no_transaction\nDROP TABLE b;\nDROP TABLE a2;\n",
+			wantErr: migratory.ErrStatementsChanged,
+		},
+		"executed statement removed": {
+			edited:  "-- +migrate up no_transaction\n-- +migrate down no_transaction\nSELECT 1;\n",
+			wantErr: migratory.ErrStatementsChanged,
+		},
+		"made transactional": {
+			edited: "-- +migrate up\nCREATE TABLE a (id INTEGER);\nCREATE TABLE b (id INTEGER);\n" +
+				"-- +migrate down\nDROP TABLE b;\nDROP TABLE a;\n",
+			wantErr: migratory.ErrDirtyDatabase,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			db := setupSQLiteDB(t)
+			ctx := context.Background()
+
+			fsys := fstest.MapFS{
+				"migrations/1_create_tables_notx.sql": {
+					Data: []byte("-- +migrate up no_transaction\nCREATE TABLE a (id INTEGER);\n" +
+						"CREATE TABLE a (id INTEGER);\n-- +migrate down no_transaction\nDROP TABLE a;\n"),
+				},
+			}
+			p, err := migratory.NewProvider(db, migratory.SQLite, migratory.WithSQLMigrationFS(fsys, "migrations"))
+			require.NoError(t, err, "migratory.NewProvider(...) error")
+
+			_, err = p.Up(ctx)
+			require.Error(t, err, "p.Up(...) of failing migration must fail")
+
+			fsys["migrations/1_create_tables_notx.sql"].Data = []byte(test.edited)
+			_, err = p.Up(ctx)
+			require.ErrorIs(t, err, test.wantErr, "p.Up(...) of edited interrupted migration error")
+
+			results, err := p.Status(ctx)
+			require.NoError(t, err, "p.Status(...) error")
+			require.Bool(t, results[0].Dirty, true, "p.Status(...) interrupted migration must stay dirty")
+		})
+	}
+}
+
+func setupSQLiteDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err, "sql.Open(...) error")
+	t.Cleanup(func() {
+		require.NoError(t, db.Close(), "db.Close()")
+	})
+
+	return db
+}
+
+func sqliteTableExists(t *testing.T, db *sql.DB, table string) (exists bool) {
+	t.Helper()
+	row := db.QueryRow("SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = ?)", table)
+	require.NoError(t, row.Scan(&exists), "db.QueryRow(...) table exists error")
+	return
+}
@@ -0,0 +1,7 @@
+-- +migrate up
+CREATE TABLE products (id INTEGER, title TEXT);
+CREATE INDEX idx_products_title ON products (title);
+
+-- +migrate down
+DROP INDEX idx_products_title;
+DROP TABLE products;
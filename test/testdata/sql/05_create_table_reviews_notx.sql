@@ -0,0 +1,5 @@
+-- +migrate up no_transaction
+CREATE TABLE reviews (id INTEGER, product_id INTEGER);
+
+-- +migrate down
+DROP TABLE reviews;